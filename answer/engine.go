@@ -0,0 +1,101 @@
+package answer
+
+import (
+	"backend/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Question is a stored student question waiting for an answer.
+type Question struct {
+	MessageID string
+	ChatID    string
+	ScsID     string
+	Text      string
+	// SpanContext links the background answer span to the request that asked the question.
+	SpanContext trace.SpanContext
+}
+
+func QuestionFromMessage(ctx context.Context, message models.PublicChatMessage, chat models.PublicChat) Question {
+	q := Question{
+		MessageID:   message.ID,
+		ChatID:      chat.ID,
+		Text:        message.Question,
+		SpanContext: trace.SpanContextFromContext(ctx),
+	}
+	if chat.ScsID != nil {
+		q.ScsID = *chat.ScsID
+	}
+	return q
+}
+
+// Engine produces an answer for a question.
+type Engine interface {
+	Answer(ctx context.Context, q Question) (string, error)
+}
+
+// HTTPEngine calls an external answering service over HTTP.
+type HTTPEngine struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewHTTPEngine(url string, timeout time.Duration) *HTTPEngine {
+	return &HTTPEngine{
+		URL: url,
+		Client: &http.Client{
+			Timeout: timeout,
+			// propagates W3C trace context to the engine and records a client span
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+	}
+}
+
+type engineRequest struct {
+	Question string `json:"question"`
+	ScsID    string `json:"scs_id"`
+	ChatID   string `json:"chat_id"`
+}
+
+type engineResponse struct {
+	Answer string `json:"answer"`
+}
+
+func (e *HTTPEngine) Answer(ctx context.Context, q Question) (string, error) {
+	body, err := json.Marshal(engineRequest{Question: q.Text, ScsID: q.ScsID, ChatID: q.ChatID})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("answer engine returned %d", resp.StatusCode)
+	}
+
+	var out engineResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", err
+	}
+	if out.Answer == "" {
+		return "", fmt.Errorf("answer engine returned an empty answer")
+	}
+	return out.Answer, nil
+}
@@ -0,0 +1,140 @@
+package answer
+
+import (
+	"backend/config"
+	"backend/metrics"
+	"backend/tracing"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+var ErrQueueFull = errors.New("answer queue is full")
+
+// Store persists answers produced by the engine.
+type Store interface {
+	SaveMessageAnswer(ctx context.Context, messageID string, answer string) error
+}
+
+// Worker answers queued questions in the background.
+type Worker struct {
+	Engine Engine
+	Store  Store
+	// OnAnswered is called after an answer was stored, e.g. to index it for duplicate detection.
+	OnAnswered func(ctx context.Context, q Question, answer string)
+
+	queue    chan Question
+	running  atomic.Bool
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func NewWorker(engine Engine, store Store, queueSize int) *Worker {
+	if queueSize <= 0 {
+		queueSize = 100
+	}
+	return &Worker{
+		Engine: engine,
+		Store:  store,
+		queue:  make(chan Question, queueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Enqueue schedules q without blocking the caller.
+func (w *Worker) Enqueue(q Question) error {
+	select {
+	case w.queue <- q:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Run processes the queue until ctx is cancelled or Stop is called.
+func (w *Worker) Run(ctx context.Context) {
+	w.running.Store(true)
+	defer w.running.Store(false)
+	defer close(w.done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			return
+		case q := <-w.queue:
+			w.process(ctx, q)
+		}
+	}
+}
+
+// Running reports whether Run is processing the queue.
+func (w *Worker) Running() bool {
+	return w.running.Load()
+}
+
+// Stop lets the question in progress finish and waits for Run to return or ctx to expire.
+// Questions still queued stay unanswered in the database.
+func (w *Worker) Stop(ctx context.Context) error {
+	w.stopOnce.Do(func() { close(w.stop) })
+	select {
+	case <-w.done:
+		if pending := len(w.queue); pending > 0 {
+			config.GetLogger().Warn("answer_queue_dropped", zap.Int("pending", pending))
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// QueueDepth reports how many questions are waiting.
+func (w *Worker) QueueDepth() int {
+	return len(w.queue)
+}
+
+func (w *Worker) process(ctx context.Context, q Question) {
+	ctx, span := tracing.Tracer.Start(ctx, "answer.process",
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.Link{SpanContext: q.SpanContext}),
+		trace.WithAttributes(
+			attribute.String("message.id", q.MessageID),
+			attribute.String("chat.id", q.ChatID),
+		),
+	)
+	defer span.End()
+
+	started := time.Now()
+	answer, err := w.Engine.Answer(ctx, q)
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	metrics.AnswerDuration.WithLabelValues(result).Observe(time.Since(started).Seconds())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "answer engine failed")
+		config.GetLogger().Error("answer_engine_failed", zap.String("message_id", q.MessageID), zap.Error(err))
+		return
+	}
+
+	if err := w.Store.SaveMessageAnswer(ctx, q.MessageID, answer); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "saving answer failed")
+		config.GetLogger().Error("answer_save_failed", zap.String("message_id", q.MessageID), zap.Error(err))
+		return
+	}
+
+	if w.OnAnswered != nil {
+		w.OnAnswered(ctx, q, answer)
+	}
+}
@@ -0,0 +1,111 @@
+// Package apperror defines the errors controllers answer with. Each carries a
+// machine-readable Code that fixes its HTTP status, so every error response has the
+// same shape and status no matter which controller or middleware produced it.
+package apperror
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type Code string
+
+const (
+	CodeValidation    Code = "validation_failed"
+	CodeUnauthorized  Code = "unauthorized"
+	CodeForbidden     Code = "forbidden"
+	CodeNotFound      Code = "not_found"
+	CodeConflict      Code = "conflict"
+	CodeUnprocessable Code = "unprocessable"
+	CodeTimeout       Code = "timeout"
+	CodeInternal      Code = "internal"
+)
+
+var statusByCode = map[Code]int{
+	CodeValidation:    http.StatusBadRequest,
+	CodeUnauthorized:  http.StatusUnauthorized,
+	CodeForbidden:     http.StatusForbidden,
+	CodeNotFound:      http.StatusNotFound,
+	CodeConflict:      http.StatusConflict,
+	CodeUnprocessable: http.StatusUnprocessableEntity,
+	CodeTimeout:       http.StatusGatewayTimeout,
+	CodeInternal:      http.StatusInternalServerError,
+}
+
+// Error is an error safe to show to API clients. Message and Details are public;
+// Err is the underlying cause and only reaches the logs.
+type Error struct {
+	Code    Code
+	Message string
+	Details any
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Status is the HTTP status of the error's code.
+func (e *Error) Status() int {
+	if status, ok := statusByCode[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// WithDetails attaches extra data for the client, e.g. the rejected rows of an import.
+func (e *Error) WithDetails(details any) *Error {
+	e.Details = details
+	return e
+}
+
+// WithCause records the error that led to e, for the logs only.
+func (e *Error) WithCause(err error) *Error {
+	e.Err = err
+	return e
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func Validation(message string) *Error   { return New(CodeValidation, message) }
+func Unauthorized(message string) *Error { return New(CodeUnauthorized, message) }
+func Forbidden(message string) *Error    { return New(CodeForbidden, message) }
+func NotFound(message string) *Error     { return New(CodeNotFound, message) }
+func Conflict(message string) *Error     { return New(CodeConflict, message) }
+
+func Unprocessable(message string) *Error { return New(CodeUnprocessable, message) }
+
+// Internal wraps an unexpected failure. message is shown to the client, err is not.
+func Internal(err error, message string) *Error {
+	return &Error{Code: CodeInternal, Message: message, Err: err}
+}
+
+// From converts any error into an *Error. Internal errors caused by pgx.ErrNoRows
+// become not_found and those caused by an expired request deadline become timeout,
+// so a lookup that finds nothing is a 404 even where the controller did not expect it.
+func From(err error) *Error {
+	var appErr *Error
+	if !errors.As(err, &appErr) {
+		appErr = Internal(err, "internal server error")
+	}
+	if appErr.Code != CodeInternal {
+		return appErr
+	}
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return &Error{Code: CodeNotFound, Message: "resource not found", Err: err}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Error{Code: CodeTimeout, Message: "request timed out", Err: err}
+	}
+	return appErr
+}
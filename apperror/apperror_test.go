@@ -0,0 +1,37 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestFrom(t *testing.T) {
+	cases := []struct {
+		name    string
+		err     error
+		code    Code
+		status  int
+		message string
+	}{
+		{"application error", Conflict("school already exists"), CodeConflict, http.StatusConflict, "school already exists"},
+		{"wrapped application error", fmt.Errorf("creating: %w", Forbidden("no")), CodeForbidden, http.StatusForbidden, "no"},
+		{"no rows", fmt.Errorf("fetching chat: %w", pgx.ErrNoRows), CodeNotFound, http.StatusNotFound, "resource not found"},
+		{"internal no rows", Internal(pgx.ErrNoRows, "failed to fetch chat"), CodeNotFound, http.StatusNotFound, "resource not found"},
+		{"deadline", Internal(context.DeadlineExceeded, "failed to fetch chat"), CodeTimeout, http.StatusGatewayTimeout, "request timed out"},
+		{"internal", Internal(errors.New("connection reset"), "failed to fetch chat"), CodeInternal, http.StatusInternalServerError, "failed to fetch chat"},
+		{"plain error", errors.New("connection reset"), CodeInternal, http.StatusInternalServerError, "internal server error"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := From(tc.err)
+			if got.Code != tc.code || got.Status() != tc.status || got.Message != tc.message {
+				t.Fatalf("got %s %d %q, want %s %d %q", got.Code, got.Status(), got.Message, tc.code, tc.status, tc.message)
+			}
+		})
+	}
+}
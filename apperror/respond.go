@@ -0,0 +1,31 @@
+package apperror
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Body is the "error" object of an error response:
+//
+//	{"status": false, "error": {"code": "not_found", "message": "chat not found", "request_id": "..."}}
+type Body struct {
+	Code      Code   `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+	Details   any    `json:"details,omitempty"`
+}
+
+// Respond records err on the request for the access log and aborts with the error
+// envelope. Handlers and middleware must return right after calling it.
+func Respond(ctx *gin.Context, err error) {
+	appErr := From(err)
+	ctx.Error(err)
+	ctx.AbortWithStatusJSON(appErr.Status(), gin.H{
+		"status": false,
+		"error": Body{
+			Code:      appErr.Code,
+			Message:   appErr.Message,
+			RequestID: ctx.GetString("request_id"),
+			Details:   appErr.Details,
+		},
+	})
+}
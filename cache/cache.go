@@ -0,0 +1,139 @@
+// Package cache keeps read-mostly query results (student profiles, SCS mappings, school,
+// class and subject names) in memcache behind a small in-process LRU. Loads of the same
+// key are collapsed into one query, and writes invalidate what they change.
+package cache
+
+import (
+	"backend/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrMiss is returned by Get when the key is not cached.
+var ErrMiss = errors.New("cache miss")
+
+// Cache stores encoded values. A ttl of zero or less keeps the value until it is evicted.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// Noop caches nothing, so every Fetch loads.
+type Noop struct{}
+
+func (Noop) Get(context.Context, string) ([]byte, error)              { return nil, ErrMiss }
+func (Noop) Set(context.Context, string, []byte, time.Duration) error { return nil }
+func (Noop) Delete(context.Context, string) error                     { return nil }
+
+const (
+	keyPrefix          = "cache:"
+	defaultLoadTimeout = 10 * time.Second
+)
+
+// Store loads values through a Cache. Cache failures are logged and fall back to the
+// loader; they never fail a request.
+type Store struct {
+	// LoadTimeout bounds a shared load, which outlives the caller that started it.
+	LoadTimeout time.Duration
+
+	cache Cache
+	group singleflight.Group
+}
+
+func New(cache Cache) *Store {
+	return &Store{cache: cache, LoadTimeout: defaultLoadTimeout}
+}
+
+// Fetch returns the cached value of key or calls load and caches its result for ttl.
+// Concurrent fetches of a missing key share a single load. It keeps the values of the
+// first caller's context but not its cancellation, so one caller giving up does not fail
+// the others; each caller stops waiting when its own context is done. Errors,
+// pgx.ErrNoRows included, are not cached.
+func Fetch[T any](ctx context.Context, s *Store, key string, ttl time.Duration, load func(context.Context) (T, error)) (T, error) {
+	var value T
+	encoded, err := s.cache.Get(ctx, key)
+	if err == nil {
+		if err := json.Unmarshal(encoded, &value); err == nil {
+			return value, nil
+		}
+		config.GetLogger().Warn("cache_decode_failed", zap.String("key", key), zap.Error(err))
+	} else if !errors.Is(err, ErrMiss) {
+		config.GetLogger().Warn("cache_get_failed", zap.String("key", key), zap.Error(err))
+	}
+
+	shared := s.group.DoChan(key, func() (any, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.LoadTimeout)
+		defer cancel()
+		loaded, err := load(loadCtx)
+		if err != nil {
+			return nil, err
+		}
+		if encoded, err := json.Marshal(loaded); err == nil {
+			s.set(loadCtx, key, encoded, ttl)
+		}
+		return loaded, nil
+	})
+	select {
+	case <-ctx.Done():
+		return value, ctx.Err()
+	case result := <-shared:
+		if result.Err != nil {
+			return value, result.Err
+		}
+		return result.Val.(T), nil
+	}
+}
+
+func (s *Store) set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	if err := s.cache.Set(ctx, key, value, ttl); err != nil {
+		config.GetLogger().Warn("cache_set_failed", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// Key names a value in namespace, e.g. Key(ctx, "scs", studentID). Keys embed the
+// namespace generation, so Bump drops the whole namespace at once.
+func (s *Store) Key(ctx context.Context, namespace string, parts ...string) string {
+	return keyPrefix + namespace + ":" + s.generation(ctx, namespace) + ":" + strings.Join(parts, ":")
+}
+
+// Invalidate drops the value of Key(ctx, namespace, parts...). Call it after the write
+// commits, so a concurrent load cannot cache the old row again.
+func (s *Store) Invalidate(ctx context.Context, namespace string, parts ...string) {
+	key := s.Key(ctx, namespace, parts...)
+	if err := s.cache.Delete(ctx, key); err != nil {
+		config.GetLogger().Warn("cache_delete_failed", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// Bump invalidates every key of namespace, for writes that change more rows than can be
+// listed, such as a rollover.
+func (s *Store) Bump(ctx context.Context, namespace string) {
+	s.set(ctx, generationKey(namespace), []byte(newGeneration()), 0)
+}
+
+func (s *Store) generation(ctx context.Context, namespace string) string {
+	key := generationKey(namespace)
+	if generation, err := s.cache.Get(ctx, key); err == nil {
+		return string(generation)
+	}
+	// an evicted generation must not revive the keys of an earlier one
+	generation := newGeneration()
+	s.set(ctx, key, []byte(generation), 0)
+	return generation
+}
+
+func generationKey(namespace string) string {
+	return keyPrefix + "generation:" + namespace
+}
+
+func newGeneration() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36)
+}
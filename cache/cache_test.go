@@ -0,0 +1,213 @@
+package cache_test
+
+import (
+	"backend/cache"
+	"backend/config"
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	config.InitLogger()
+	if err := config.ConfigureLogger("fatal", "json"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+type profile struct {
+	Name string `json:"name"`
+}
+
+func TestFetchCachesUntilInvalidated(t *testing.T) {
+	store := cache.New(cache.NewLRU(10))
+	ctx := context.Background()
+	var loads atomic.Int32
+	fetch := func(name string) profile {
+		t.Helper()
+		got, err := cache.Fetch(ctx, store, store.Key(ctx, "student", "1"), time.Minute, func(context.Context) (profile, error) {
+			loads.Add(1)
+			return profile{Name: name}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	if got := fetch("Asha"); got.Name != "Asha" {
+		t.Fatalf("got %+v", got)
+	}
+	if got := fetch("Asha K"); got.Name != "Asha" || loads.Load() != 1 {
+		t.Fatalf("got %+v after %d loads, want the cached value", got, loads.Load())
+	}
+
+	store.Invalidate(ctx, "student", "1")
+	if got := fetch("Asha K"); got.Name != "Asha K" {
+		t.Fatalf("got %+v after invalidation", got)
+	}
+
+	store.Bump(ctx, "student")
+	if got := fetch("Asha Kumar"); got.Name != "Asha Kumar" || loads.Load() != 3 {
+		t.Fatalf("got %+v after bumping the namespace", got)
+	}
+}
+
+func TestFetchDoesNotCacheErrors(t *testing.T) {
+	store := cache.New(cache.NewLRU(10))
+	ctx := context.Background()
+	notFound := errors.New("no rows")
+	if _, err := cache.Fetch(ctx, store, "k", time.Minute, func(context.Context) (int, error) { return 0, notFound }); !errors.Is(err, notFound) {
+		t.Fatalf("got %v", err)
+	}
+	if got, err := cache.Fetch(ctx, store, "k", time.Minute, func(context.Context) (int, error) { return 7, nil }); err != nil || got != 7 {
+		t.Fatalf("got %d, %v after a failed load", got, err)
+	}
+}
+
+func TestFetchCollapsesConcurrentLoads(t *testing.T) {
+	store := cache.New(cache.Noop{})
+	release := make(chan struct{})
+	var loads atomic.Int32
+	load := func(context.Context) ([]string, error) {
+		loads.Add(1)
+		<-release
+		return []string{"Science"}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := cache.Fetch(context.Background(), store, "subjects", time.Minute, load)
+			if err != nil || len(got) != 1 {
+				t.Errorf("got %v, %v", got, err)
+			}
+		}()
+	}
+	// let the goroutines queue up behind the first load
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("got %d loads, want 1", n)
+	}
+
+	// nothing is kept by Noop
+	cache.Fetch(context.Background(), store, "subjects", time.Minute, load)
+	if n := loads.Load(); n != 2 {
+		t.Fatalf("got %d loads, want Noop to load again", n)
+	}
+}
+
+func TestFetchSharedLoadOutlivesCanceledCaller(t *testing.T) {
+	store := cache.New(cache.NewLRU(10))
+	started, release := make(chan struct{}), make(chan struct{})
+	load := func(ctx context.Context) (profile, error) {
+		close(started)
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return profile{}, ctx.Err()
+		}
+		return profile{Name: "Asha"}, nil
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := cache.Fetch(first, store, "student:1", time.Minute, load)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan profile, 1)
+	go func() {
+		got, err := cache.Fetch(context.Background(), store, "student:1", time.Minute, load)
+		if err != nil {
+			t.Errorf("second caller: %v", err)
+		}
+		second <- got
+	}()
+	// the first caller gives up while the second waits on its load
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first caller: got %v, want context.Canceled", err)
+	}
+	close(release)
+	if got := <-second; got.Name != "Asha" {
+		t.Fatalf("second caller: got %+v", got)
+	}
+}
+
+func TestFetchSharedLoadTimesOut(t *testing.T) {
+	store := cache.New(cache.Noop{})
+	store.LoadTimeout = 10 * time.Millisecond
+	_, err := cache.Fetch(context.Background(), store, "student:1", time.Minute, func(ctx context.Context) (profile, error) {
+		<-ctx.Done()
+		return profile{}, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	lru := cache.NewLRU(2)
+	ctx := context.Background()
+	lru.Set(ctx, "a", []byte("1"), 0)
+	lru.Set(ctx, "b", []byte("2"), 0)
+	lru.Get(ctx, "a")
+	lru.Set(ctx, "c", []byte("3"), 0)
+
+	if _, err := lru.Get(ctx, "b"); !errors.Is(err, cache.ErrMiss) {
+		t.Fatal("least recently used entry was kept")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, err := lru.Get(ctx, key); err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+	}
+
+	lru.Set(ctx, "short", []byte("4"), 20*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	if _, err := lru.Get(ctx, "short"); !errors.Is(err, cache.ErrMiss) {
+		t.Fatal("expired entry was returned")
+	}
+	// "short" evicted "a" and was dropped on lookup, leaving "c"
+	if lru.Len() != 1 {
+		t.Fatalf("got %d entries, want 1", lru.Len())
+	}
+}
+
+func TestTieredCapsLocalCopies(t *testing.T) {
+	local, remote := cache.NewLRU(10), cache.NewLRU(10)
+	tiered := cache.NewTiered(local, remote, 20*time.Millisecond)
+	ctx := context.Background()
+
+	tiered.Set(ctx, "k", []byte("v1"), time.Minute)
+	// another instance changes the remote value; this one sees it once its copy expires
+	remote.Set(ctx, "k", []byte("v2"), time.Minute)
+	if got, _ := tiered.Get(ctx, "k"); string(got) != "v1" {
+		t.Fatalf("got %s, want the local copy", got)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if got, _ := tiered.Get(ctx, "k"); string(got) != "v2" {
+		t.Fatalf("got %s, want the remote value after the local TTL", got)
+	}
+	if got, err := local.Get(ctx, "k"); err != nil || string(got) != "v2" {
+		t.Fatalf("remote hit was not copied locally: %s, %v", got, err)
+	}
+
+	tiered.Delete(ctx, "k")
+	if _, err := tiered.Get(ctx, "k"); !errors.Is(err, cache.ErrMiss) {
+		t.Fatalf("got %v after delete, want a miss", err)
+	}
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"backend/metrics"
+	"backend/tracing"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Memcache adapts a memcache client. Lookups are counted under the cache label name.
+type Memcache struct {
+	client *memcache.Client
+	name   string
+}
+
+func NewMemcache(client *memcache.Client, name string) *Memcache {
+	return &Memcache{client: client, name: name}
+}
+
+func (c *Memcache) Get(ctx context.Context, key string) ([]byte, error) {
+	_, span := tracing.StartMemcacheSpan(ctx, "get")
+	defer span.End()
+
+	item, err := c.client.Get(key)
+	metrics.ObserveMemcacheGet(c.name, err)
+	switch {
+	case errors.Is(err, memcache.ErrCacheMiss):
+		return nil, ErrMiss
+	case err != nil:
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	return item.Value, nil
+}
+
+func (c *Memcache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, span := tracing.StartMemcacheSpan(ctx, "set")
+	defer span.End()
+
+	err := c.client.Set(&memcache.Item{Key: key, Value: value, Expiration: expiration(ttl)})
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+func (c *Memcache) Delete(ctx context.Context, key string) error {
+	_, span := tracing.StartMemcacheSpan(ctx, "delete")
+	defer span.End()
+
+	err := c.client.Delete(key)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return nil
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// maxRelativeExpiration is the longest expiration memcache reads as seconds from now;
+// larger values are taken as a Unix time.
+const maxRelativeExpiration = 30 * 24 * time.Hour
+
+// expiration converts ttl to memcache seconds, rounding up so short TTLs do not
+// become 0, which never expires.
+func expiration(ttl time.Duration) int32 {
+	switch {
+	case ttl <= 0:
+		return 0
+	case ttl > maxRelativeExpiration:
+		return int32(time.Now().Add(ttl).Unix())
+	}
+	return int32((ttl + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Tiered reads the local cache before the remote one. Local copies live at most
+// localTTL, which bounds how long an instance serves a value another instance
+// invalidated in the remote cache.
+type Tiered struct {
+	local    Cache
+	remote   Cache
+	localTTL time.Duration
+}
+
+func NewTiered(local Cache, remote Cache, localTTL time.Duration) *Tiered {
+	return &Tiered{local: local, remote: remote, localTTL: localTTL}
+}
+
+func (c *Tiered) Get(ctx context.Context, key string) ([]byte, error) {
+	if value, err := c.local.Get(ctx, key); err == nil {
+		return value, nil
+	}
+	value, err := c.remote.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	c.local.Set(ctx, key, value, c.localTTL)
+	return value, nil
+}
+
+func (c *Tiered) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	localTTL := c.localTTL
+	if ttl > 0 && ttl < localTTL {
+		localTTL = ttl
+	}
+	c.local.Set(ctx, key, value, localTTL)
+	return c.remote.Set(ctx, key, value, ttl)
+}
+
+func (c *Tiered) Delete(ctx context.Context, key string) error {
+	return errors.Join(c.local.Delete(ctx, key), c.remote.Delete(ctx, key))
+}
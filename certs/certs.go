@@ -0,0 +1,134 @@
+// Package certs serves TLS certificates from files and reloads them when the files
+// change, so renewed certificates (cert-manager, certbot) take effect without a restart.
+package certs
+
+import (
+	"backend/config"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Reloader holds the server certificate and, optionally, the CAs client certificates
+// are verified against.
+type Reloader struct {
+	certFile, keyFile, clientCAFile string
+
+	config  atomic.Pointer[tls.Config]
+	version atomic.Pointer[string] // modification stamp of the loaded files
+}
+
+// NewReloader loads the key pair and client CAs. clientCAFile may be empty; otherwise
+// clients may present a certificate, which is then verified (see middleware.RequireClientCert).
+func NewReloader(certFile string, keyFile string, clientCAFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the files again. On failure the previous certificate stays in use.
+func (r *Reloader) Reload() error {
+	version, err := r.stamp()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading key pair: %w", err)
+	}
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if r.clientCAFile != "" {
+		pem, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("reading client CAs: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("no certificates found in " + r.clientCAFile)
+		}
+		// only admin routes insist on a certificate, so others may connect without one
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		cfg.ClientCAs = pool
+	}
+
+	r.config.Store(cfg)
+	r.version.Store(&version)
+	return nil
+}
+
+// stamp identifies the current contents of the files by size and modification time.
+func (r *Reloader) stamp() (string, error) {
+	var stamp string
+	for _, file := range []string{r.certFile, r.keyFile, r.clientCAFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		stamp += fmt.Sprintf("%s:%d:%d;", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return stamp, nil
+}
+
+// Watch checks the files every interval and reloads them when they changed, until ctx
+// is done. Failed reloads are logged and retried on the next change.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		version, err := r.stamp()
+		if err != nil {
+			config.GetLogger().Warn("tls_reload_failed", zap.Error(err))
+			continue
+		}
+		if version == *r.version.Load() {
+			continue
+		}
+		if err := r.Reload(); err != nil {
+			config.GetLogger().Warn("tls_reload_failed", zap.Error(err))
+			// remember the broken files so they are not retried every tick
+			r.version.Store(&version)
+			continue
+		}
+		config.GetLogger().Info("tls certificates reloaded", zap.String("cert_file", r.certFile))
+	}
+}
+
+// TLSConfig is the server configuration. Every handshake uses the most recently loaded
+// files; HTTP/2 is negotiated through ALPN.
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		// http.Server.ServeTLS requires a certificate source on the outer config
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &r.config.Load().Certificates[0], nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.config.Load(), nil
+		},
+	}
+}
@@ -0,0 +1,233 @@
+package certs_test
+
+import (
+	"backend/certs"
+	"backend/config"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	config.InitLogger()
+	if err := config.ConfigureLogger("fatal", "json"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// authority signs certificates for the tests.
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newAuthority(t *testing.T) *authority {
+	t.Helper()
+	key := newKey(t)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &authority{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for name, usable by servers and clients.
+func (a *authority) issue(t *testing.T, name string, serial int64) ([]byte, []byte) {
+	t.Helper()
+	key := newKey(t)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func write(t *testing.T, path string, data []byte, modified time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// some file systems keep coarse timestamps; make the change visible to the watcher
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serve runs an HTTPS server with the reloader's configuration. Its handler reports the
+// protocol and whether a verified client certificate was presented.
+func serve(t *testing.T, reloader *certs.Reloader) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{
+		TLSConfig: reloader.TLSConfig(),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Proto", r.Proto)
+			w.Header().Set("X-Client-Verified", strconv.FormatBool(len(r.TLS.VerifiedChains) > 0))
+		}),
+	}
+	go server.ServeTLS(listener, "", "")
+	t.Cleanup(func() { server.Close() })
+	return "https://" + listener.Addr().String()
+}
+
+func client(ca *authority, clientCert *tls.Certificate) *http.Client {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	cfg := &tls.Config{RootCAs: roots}
+	if clientCert != nil {
+		cfg.Certificates = []tls.Certificate{*clientCert}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: cfg, ForceAttemptHTTP2: true, DisableKeepAlives: true}}
+}
+
+func get(t *testing.T, c *http.Client, url string) *http.Response {
+	t.Helper()
+	resp, err := c.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestServesHTTP2AndReloadsChangedCertificate(t *testing.T) {
+	ca := newAuthority(t)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	cert, key := ca.issue(t, "localhost", 10)
+	loaded := time.Now().Add(-time.Minute)
+	write(t, certFile, cert, loaded)
+	write(t, keyFile, key, loaded)
+
+	reloader, err := certs.NewReloader(certFile, keyFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx, 10*time.Millisecond)
+	url := serve(t, reloader)
+
+	resp := get(t, client(ca, nil), url)
+	if resp.ProtoMajor != 2 || resp.TLS.PeerCertificates[0].SerialNumber.Int64() != 10 {
+		t.Fatalf("got %s with serial %d", resp.Proto, resp.TLS.PeerCertificates[0].SerialNumber)
+	}
+
+	cert, key = ca.issue(t, "localhost", 11)
+	write(t, certFile, cert, time.Now())
+	write(t, keyFile, key, time.Now())
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp := get(t, client(ca, nil), url)
+		if resp.TLS.PeerCertificates[0].SerialNumber.Int64() == 11 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("renewed certificate was not picked up")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// a broken renewal keeps the last good certificate
+	write(t, certFile, []byte("not a certificate"), time.Now().Add(time.Minute))
+	if err := reloader.Reload(); err == nil {
+		t.Fatal("reloading a broken certificate succeeded")
+	}
+	if resp := get(t, client(ca, nil), url); resp.TLS.PeerCertificates[0].SerialNumber.Int64() != 11 {
+		t.Fatalf("got serial %d after a failed reload, want 11", resp.TLS.PeerCertificates[0].SerialNumber)
+	}
+}
+
+func TestVerifiesClientCertificates(t *testing.T) {
+	ca := newAuthority(t)
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	cert, key := ca.issue(t, "localhost", 20)
+	write(t, certFile, cert, time.Now())
+	write(t, keyFile, key, time.Now())
+	write(t, caFile, ca.pem, time.Now())
+
+	reloader, err := certs.NewReloader(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := serve(t, reloader)
+
+	if resp := get(t, client(ca, nil), url); resp.Header.Get("X-Client-Verified") != "false" {
+		t.Fatal("connection without a client certificate was marked verified")
+	}
+
+	clientPEM, clientKey := ca.issue(t, "admin-tool", 21)
+	clientCert, err := tls.X509KeyPair(clientPEM, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp := get(t, client(ca, &clientCert), url); resp.Header.Get("X-Client-Verified") != "true" {
+		t.Fatal("client certificate signed by the CA was not verified")
+	}
+
+	stranger := newAuthority(t)
+	strangerPEM, strangerKey := stranger.issue(t, "intruder", 22)
+	strangerCert, err := tls.X509KeyPair(strangerPEM, strangerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client(ca, &strangerCert).Get(url); err == nil {
+		t.Fatal("client certificate of another CA was accepted")
+	}
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"backend/config"
+	"backend/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var loginPaths = map[string]string{
+	config.RoleStudent: "/v1/public/students/login",
+	config.RoleTeacher: "/v1/public/teacher/login",
+	config.RoleAdmin:   "/v1/public/admin/login",
+}
+
+var errNoCredentials = errors.New("client: no credentials to log in again")
+
+// Login logs in as role and uses the token for the following requests. The credentials
+// are kept in memory to log in again when the token is rejected.
+func (c *Client) Login(ctx context.Context, role string, email string, password string) (models.LoginResponse, error) {
+	path, ok := loginPaths[role]
+	if !ok {
+		return models.LoginResponse{}, fmt.Errorf("client: unknown role %q", role)
+	}
+
+	var resp models.LoginResponse
+	body := models.StudentLoginRequest{Email: email, Password: password}
+	if err := c.send(ctx, request{method: http.MethodPost, path: path, body: body}, &resp); err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	c.setToken(resp.Token)
+	c.mu.Lock()
+	c.login = &credentials{role: role, email: email, password: password}
+	c.mu.Unlock()
+	return resp, nil
+}
+
+// Refresh exchanges the current token for a fresh one.
+func (c *Client) Refresh(ctx context.Context) error {
+	var resp models.TokenResponse
+	if err := c.send(ctx, request{method: http.MethodPost, path: "/v1/auth/refresh", auth: true}, &resp); err != nil {
+		return err
+	}
+	c.setToken(resp.Token)
+	return nil
+}
+
+// refreshIfExpiring refreshes a token that expires within the refresh window. Expired
+// tokens cannot be refreshed; the 401 they get leads to a new login instead.
+func (c *Client) refreshIfExpiring(ctx context.Context) error {
+	c.mu.Lock()
+	left := time.Until(c.expires)
+	due := !c.expires.IsZero() && left > 0 && left < c.refreshWindow
+	c.mu.Unlock()
+	if !due {
+		return nil
+	}
+	return c.Refresh(ctx)
+}
+
+func (c *Client) relogin(ctx context.Context) error {
+	c.mu.Lock()
+	login := c.login
+	c.mu.Unlock()
+	if login == nil {
+		return errNoCredentials
+	}
+	_, err := c.Login(ctx, login.role, login.email, login.password)
+	return err
+}
@@ -0,0 +1,270 @@
+// Package client is a typed Go client for the /v1 API. Requests and responses use the
+// models types the server itself encodes, so a field added to a model reaches the client
+// without a separate copy to keep in sync.
+//
+// The client keeps the bearer token of the last login, refreshes it shortly before it
+// expires and, when it still holds the credentials, logs in again once a request is
+// rejected as unauthorized. Idempotent requests are retried on network errors and on
+// 429, 502, 503 and 504 responses.
+package client
+
+import (
+	"backend/apperror"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultRetries       = 3
+	DefaultRetryBackoff  = 200 * time.Millisecond
+	DefaultRefreshWindow = 5 * time.Minute
+	maxRetryBackoff      = 5 * time.Second
+)
+
+// Error is an error envelope returned by the server.
+type Error struct {
+	StatusCode int
+	apperror.Body
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// IsCode reports whether err is an *Error with the given code.
+func IsCode(err error, code apperror.Code) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+type Client struct {
+	baseURL       string
+	http          *http.Client
+	retries       int
+	retryBackoff  time.Duration
+	refreshWindow time.Duration
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+	login   *credentials // set by Login, used to log in again after a 401
+}
+
+type credentials struct {
+	role, email, password string
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sets the underlying client, e.g. to configure timeouts or transport.
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) { c.http = h }
+}
+
+// WithRetries sets how often an idempotent request is retried and the initial backoff,
+// doubled after every attempt. Zero retries disables retrying.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) { c.retries, c.retryBackoff = retries, backoff }
+}
+
+// WithRefreshWindow sets how long before its expiry the token is refreshed.
+func WithRefreshWindow(d time.Duration) Option {
+	return func(c *Client) { c.refreshWindow = d }
+}
+
+// WithToken starts the client with an existing token instead of a login.
+func WithToken(token string) Option {
+	return func(c *Client) { c.setToken(token) }
+}
+
+// New returns a client for the server at baseURL, e.g. https://api.example.com.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:       strings.TrimSuffix(baseURL, "/"),
+		http:          http.DefaultClient,
+		retries:       DefaultRetries,
+		retryBackoff:  DefaultRetryBackoff,
+		refreshWindow: DefaultRefreshWindow,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Token is the current bearer token, "" before a login.
+func (c *Client) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+func (c *Client) setToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token, c.expires = token, tokenExpiry(token)
+}
+
+// tokenExpiry reads the exp claim without verifying the token; the server does that.
+// It returns the zero time when the token has none, which disables refreshing.
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if json.Unmarshal(payload, &claims) != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
+
+// request describes one call. auth requests carry the bearer token.
+type request struct {
+	method string
+	path   string
+	body   any
+	auth   bool
+}
+
+// idempotent requests may be sent again without changing the outcome.
+func (r request) idempotent() bool {
+	switch r.method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// do sends req and decodes the "data" of the success envelope into out.
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	if req.auth {
+		if err := c.refreshIfExpiring(ctx); err != nil {
+			return err
+		}
+	}
+
+	err := c.send(ctx, req, out)
+	if req.auth && IsCode(err, apperror.CodeUnauthorized) && c.relogin(ctx) == nil {
+		// the token was rejected before the request ran, so any method may be resent
+		err = c.send(ctx, req, out)
+	}
+	return err
+}
+
+// send makes the request, retrying idempotent ones on transient failures.
+func (c *Client) send(ctx context.Context, req request, out any) error {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+	}
+
+	attempts := 1
+	if req.idempotent() {
+		attempts += c.retries
+	}
+	backoff := c.retryBackoff
+	for attempt := 1; ; attempt++ {
+		wait, err := c.attempt(ctx, req, body, out)
+		if err == nil || wait < 0 || attempt >= attempts {
+			return err
+		}
+		if wait == 0 {
+			wait = backoff
+			backoff = min(backoff*2, maxRetryBackoff)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// attempt makes one request. A non-negative wait marks the failure as retryable; a
+// positive one is the delay the server asked for in Retry-After.
+func (c *Client) attempt(ctx context.Context, req request, body []byte, out any) (time.Duration, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, c.baseURL+req.path, reader)
+	if err != nil {
+		return -1, err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if token := c.Token(); req.auth && token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return -1, err
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		err := decodeError(resp)
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return retryAfter(resp), err
+		}
+		return -1, err
+	}
+
+	envelope := struct {
+		Data any `json:"data"`
+	}{Data: out}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return -1, fmt.Errorf("decoding %s %s: %w", req.method, req.path, err)
+	}
+	return -1, nil
+}
+
+func decodeError(resp *http.Response) error {
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	var envelope struct {
+		Error *apperror.Body `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil || envelope.Error == nil {
+		// proxies in front of the server answer without the envelope
+		apiErr.Code, apiErr.Message = apperror.CodeInternal, http.StatusText(resp.StatusCode)
+		return apiErr
+	}
+	apiErr.Body = *envelope.Error
+	return apiErr
+}
+
+// retryAfter reads a Retry-After header given in seconds, 0 when there is none.
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return min(time.Duration(seconds)*time.Second, maxRetryBackoff)
+}
@@ -0,0 +1,213 @@
+package client_test
+
+import (
+	"backend/apperror"
+	"backend/client"
+	"backend/config"
+	"backend/lifecycle"
+	"backend/models"
+	"backend/repository/memory"
+	"backend/routes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	config.InitLogger()
+	if err := config.ConfigureLogger("fatal", "json"); err != nil {
+		panic(err)
+	}
+	config.LoadEnv()
+	os.Exit(m.Run())
+}
+
+const password = "secret-123"
+
+// newServer serves the real routes over a memory store holding one student with a chat
+// of n messages.
+func newServer(t *testing.T, n int) (*httptest.Server, models.UserAccount, models.PublicChat) {
+	t.Helper()
+	ctx := context.Background()
+	store := memory.NewStore()
+	student, err := store.CreateUser(ctx, models.CreateUserRequest{Role: config.RoleStudent, ExternalID: "S-1", FullName: "Asha", Email: "asha@demo.local", Password: password})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chat := store.AddChat(models.PublicChat{StudentID: &student.ID})
+	for i := 0; i < n; i++ {
+		if _, err := store.CreateChatMessage(ctx, chat.ID, fmt.Sprintf("Question %d?", i), nil, models.ModerationVerdict{Status: models.ModerationApproved, Reasons: []string{}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	readiness := &lifecycle.Readiness{}
+	readiness.SetReady(true)
+	server := httptest.NewServer(routes.SetupRoutes(memory.NewSet(store), routes.Services{Readiness: readiness}))
+	t.Cleanup(server.Close)
+	return server, student, chat
+}
+
+func TestStudentEndpoints(t *testing.T) {
+	server, student, chat := newServer(t, 3)
+	ctx := context.Background()
+	c := client.New(server.URL)
+
+	if _, err := c.Me(ctx); !client.IsCode(err, apperror.CodeUnauthorized) {
+		t.Fatalf("before login: got %v, want unauthorized", err)
+	}
+	if _, err := c.Login(ctx, config.RoleStudent, student.Email, "wrong-123"); !client.IsCode(err, apperror.CodeUnauthorized) {
+		t.Fatalf("wrong password: got %v, want unauthorized", err)
+	}
+	login, err := c.Login(ctx, config.RoleStudent, student.Email, password)
+	if err != nil || login.User.ID != student.ID || c.Token() == "" {
+		t.Fatalf("login: got %+v, %v", login, err)
+	}
+
+	profile, err := c.Profile(ctx)
+	if err != nil || profile.UserID != student.ID {
+		t.Fatalf("profile: got %+v, %v", profile, err)
+	}
+	me, err := c.Me(ctx)
+	if err != nil || me.ID != student.ID {
+		t.Fatalf("me: got %+v, %v", me, err)
+	}
+	chats, err := c.Chats(ctx, models.Page{})
+	if err != nil || len(chats) != 1 || chats[0].ID != chat.ID {
+		t.Fatalf("chats: got %+v, %v", chats, err)
+	}
+	if got, err := c.Chat(ctx, chat.ID); err != nil || got.ID != chat.ID {
+		t.Fatalf("chat: got %+v, %v", got, err)
+	}
+	if _, err := c.Chat(ctx, "00000000-0000-4000-8000-000000000000"); !client.IsCode(err, apperror.CodeNotFound) {
+		t.Fatalf("missing chat: got %v, want not found", err)
+	}
+	page, err := c.Messages(ctx, chat.ID, models.Page{Limit: 2, Offset: 1})
+	if err != nil || len(page) != 2 || page[0].Question != "Question 1?" {
+		t.Fatalf("messages: got %+v, %v", page, err)
+	}
+	asked, err := c.AskQuestion(ctx, chat.ID, models.AskQuestionRequest{Question: "Why is the sky blue?"})
+	if err != nil || asked.Message == nil || asked.Message.Question != "Why is the sky blue?" {
+		t.Fatalf("ask: got %+v, %v", asked, err)
+	}
+	if _, err := c.SCSMapping(ctx); err != nil {
+		t.Fatalf("scs mapping: %v", err)
+	}
+}
+
+func TestAllMessagesWalksEveryPage(t *testing.T) {
+	n := client.DefaultPageSize*2 + 5
+	server, student, chat := newServer(t, n)
+	ctx := context.Background()
+	c := client.New(server.URL)
+	if _, err := c.Login(ctx, config.RoleStudent, student.Email, password); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for message, err := range c.AllMessages(ctx, chat.ID) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, message.Question)
+	}
+	if len(got) != n || got[0] != fmt.Sprintf("Question %d?", n-1) || got[n-1] != "Question 0?" {
+		t.Fatalf("got %d messages from %q to %q", len(got), got[0], got[len(got)-1])
+	}
+
+	count := 0
+	for range c.AllMessages(ctx, chat.ID) {
+		if count++; count == 3 {
+			break
+		}
+	}
+	if count != 3 {
+		t.Fatalf("stopped after %d messages, want 3", count)
+	}
+}
+
+func TestRefreshesExpiringToken(t *testing.T) {
+	server, student, _ := newServer(t, 0)
+	token, err := config.GenerateJWTWithTTL(student.ID, student.Email, config.RoleStudent, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := client.New(server.URL, client.WithToken(token), client.WithRefreshWindow(5*time.Minute))
+	if _, err := c.Profile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if c.Token() == token {
+		t.Fatal("token expiring within the refresh window was not refreshed")
+	}
+}
+
+func TestLogsInAgainAfterUnauthorized(t *testing.T) {
+	var logins, requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/v1/public/students/login":
+			fmt.Fprintf(w, `{"status":true,"data":{"token":"token-%d"}}`, logins.Add(1))
+		case r.Header.Get("Authorization") != "Bearer token-2":
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"status":false,"error":{"code":"unauthorized","message":"invalid token"}}`)
+		default:
+			requests.Add(1)
+			fmt.Fprint(w, `{"status":true,"data":{"user_id":"u-1"}}`)
+		}
+	}))
+	defer server.Close()
+
+	c := client.New(server.URL)
+	ctx := context.Background()
+	if _, err := c.Login(ctx, config.RoleStudent, "asha@demo.local", password); err != nil {
+		t.Fatal(err)
+	}
+	profile, err := c.Profile(ctx)
+	if err != nil || profile.UserID != "u-1" || logins.Load() != 2 || requests.Load() != 1 {
+		t.Fatalf("got %+v, %v after %d logins", profile, err, logins.Load())
+	}
+}
+
+func TestRetriesOnlyIdempotentRequests(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"status":false,"error":{"code":"internal","message":"busy"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"status":true,"data":{"user_id":"u-1"}}`)
+	}))
+	defer server.Close()
+
+	c := client.New(server.URL, client.WithToken("token"), client.WithRetries(3, time.Millisecond))
+	ctx := context.Background()
+	if _, err := c.Profile(ctx); err != nil || calls.Load() != 3 {
+		t.Fatalf("GET: got %v after %d calls, want success after 3", err, calls.Load())
+	}
+
+	calls.Store(0)
+	_, err := c.AskQuestion(ctx, "chat", models.AskQuestionRequest{Question: "Why?"})
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Fatalf("POST: got %v after %d calls, want one 503", err, calls.Load())
+	}
+
+	calls.Store(0)
+	c = client.New(server.URL, client.WithToken("token"), client.WithRetries(1, time.Millisecond))
+	if _, err := c.Profile(ctx); !errors.As(err, &apiErr) || calls.Load() != 2 {
+		t.Fatalf("GET with one retry: got %v after %d calls, want a 503 after 2", err, calls.Load())
+	}
+}
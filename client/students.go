@@ -0,0 +1,121 @@
+package client
+
+import (
+	"backend/models"
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// DefaultPageSize is the page size of the All* iterators.
+const DefaultPageSize = 50
+
+// Profile returns the claims of the current token.
+func (c *Client) Profile(ctx context.Context) (models.TokenProfile, error) {
+	var profile models.TokenProfile
+	err := c.do(ctx, request{method: http.MethodGet, path: "/v1/students/profile", auth: true}, &profile)
+	return profile, err
+}
+
+// Me returns the logged in student's record.
+func (c *Client) Me(ctx context.Context) (models.Student, error) {
+	var student models.Student
+	err := c.do(ctx, request{method: http.MethodGet, path: "/v1/students/me", auth: true}, &student)
+	return student, err
+}
+
+// Chats returns one page of the student's chats, newest first. A zero page.Limit
+// returns every chat.
+func (c *Client) Chats(ctx context.Context, page models.Page) ([]models.PublicChat, error) {
+	var chats []models.PublicChat
+	err := c.do(ctx, request{method: http.MethodGet, path: "/v1/students/chats" + pageQuery(page), auth: true}, &chats)
+	return chats, err
+}
+
+// AllChats iterates over the student's chats a page at a time. It stops after the
+// first error.
+func (c *Client) AllChats(ctx context.Context) iter.Seq2[models.PublicChat, error] {
+	return paginate(func(page models.Page) ([]models.PublicChat, error) {
+		return c.Chats(ctx, page)
+	})
+}
+
+// Chat returns one of the student's chats.
+func (c *Client) Chat(ctx context.Context, chatID string) (models.PublicChat, error) {
+	var chat models.PublicChat
+	err := c.do(ctx, request{method: http.MethodGet, path: "/v1/students/chats/" + url.PathEscape(chatID), auth: true}, &chat)
+	return chat, err
+}
+
+// Messages returns one page of a chat's messages, newest first.
+func (c *Client) Messages(ctx context.Context, chatID string, page models.Page) ([]models.PublicChatMessage, error) {
+	var messages []models.PublicChatMessage
+	path := "/v1/students/chats/" + url.PathEscape(chatID) + "/messages" + pageQuery(page)
+	err := c.do(ctx, request{method: http.MethodGet, path: path, auth: true}, &messages)
+	return messages, err
+}
+
+// AllMessages iterates over a chat's messages, newest first. Messages asked while
+// iterating shift the pages, so one may be yielded twice.
+func (c *Client) AllMessages(ctx context.Context, chatID string) iter.Seq2[models.PublicChatMessage, error] {
+	return paginate(func(page models.Page) ([]models.PublicChatMessage, error) {
+		return c.Messages(ctx, chatID, page)
+	})
+}
+
+// AskQuestion asks a question in a chat. The response either holds the stored message
+// or, with Duplicate set, answered questions that look the same; see
+// models.AskQuestionRequest for how to accept one of those or ask anyway.
+func (c *Client) AskQuestion(ctx context.Context, chatID string, req models.AskQuestionRequest) (models.AskQuestionResponse, error) {
+	var resp models.AskQuestionResponse
+	path := "/v1/students/chats/" + url.PathEscape(chatID) + "/messages"
+	err := c.do(ctx, request{method: http.MethodPost, path: path, body: req, auth: true}, &resp)
+	return resp, err
+}
+
+// SCSMapping returns the student's subjects grouped by academic year.
+func (c *Client) SCSMapping(ctx context.Context) ([]models.YearWiseDetails, error) {
+	var details []models.YearWiseDetails
+	err := c.do(ctx, request{method: http.MethodGet, path: "/v1/students/scs_mapping", auth: true}, &details)
+	return details, err
+}
+
+func pageQuery(page models.Page) string {
+	query := url.Values{}
+	if page.Limit > 0 {
+		query.Set("limit", strconv.Itoa(page.Limit))
+	}
+	if page.Offset > 0 {
+		query.Set("offset", strconv.Itoa(page.Offset))
+	}
+	if len(query) == 0 {
+		return ""
+	}
+	return "?" + query.Encode()
+}
+
+// paginate turns fetch into an iterator that requests pages until one comes back short.
+func paginate[T any](fetch func(models.Page) ([]T, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		page := models.Page{Limit: DefaultPageSize}
+		for {
+			items, err := fetch(page)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if len(items) < page.Limit {
+				return
+			}
+			page.Offset += len(items)
+		}
+	}
+}
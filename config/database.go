@@ -3,31 +3,85 @@ package config
 import (
 	"context"
 	"fmt"
+	"time"
+
+	"github.com/exaring/otelpgx"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"log"
+	"go.uber.org/zap"
+)
+
+const (
+	connectBackoff    = 500 * time.Millisecond
+	maxConnectBackoff = 10 * time.Second
 )
 
-func Connect() *pgxpool.Pool {
+// Connect opens the pool described by DatabaseDSN with the DB_* pool settings. It waits
+// up to DB_CONNECT_TIMEOUT for the database, retrying with exponential backoff, so a
+// service started alongside its database does not exit before the database is up.
+func Connect(ctx context.Context) (*pgxpool.Pool, error) {
 	globalEnv := GetEnv()
-	postgresHost := globalEnv.PostgresHost
-	postgresUser := globalEnv.PostgresUser
-	postgresPassword := globalEnv.PostgresPassword
-	postgresDb := globalEnv.PostgresDB
-	dsn := fmt.Sprintf("postgres://%s:%s@%s/%s", postgresUser, postgresPassword, postgresHost, postgresDb)
-	if dsn == "" {
-		log.Fatal("DATABASE_URL env variable is required")
+	pool, err := newPool(ctx, globalEnv.DatabaseDSN())
+	if err != nil {
+		return nil, err
 	}
 
-	pool, err := pgxpool.New(context.Background(), dsn)
-	if err != nil {
-		log.Fatalf("❌ Unable to connect to database: %v", err)
+	ctx, cancel := context.WithTimeout(ctx, globalEnv.DBConnectTimeout)
+	defer cancel()
+	backoff := connectBackoff
+	for attempt := 1; ; attempt++ {
+		err := pool.Ping(ctx)
+		if err == nil {
+			break
+		}
+		GetLogger().Warn("database_connect_failed", zap.Int("attempt", attempt), zap.Duration("retry_in", backoff), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			pool.Close()
+			return nil, fmt.Errorf("cannot reach database after %d attempts: %w", attempt, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+
+	GetLogger().Info("✅ Connected to database")
+	return pool, nil
+}
+
+// ConnectReplicas opens a pool per DATABASE_REPLICA_URLS entry. Replicas are not waited
+// for: an unreachable replica is skipped by the read router until it catches up.
+func ConnectReplicas(ctx context.Context) ([]*pgxpool.Pool, error) {
+	var pools []*pgxpool.Pool
+	for i, dsn := range GetEnv().DatabaseReplicaURLs {
+		pool, err := newPool(ctx, dsn)
+		if err != nil {
+			for _, opened := range pools {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("replica %d: %w", i, err)
+		}
+		pools = append(pools, pool)
 	}
+	return pools, nil
+}
 
-	// check connection
-	if err := pool.Ping(context.Background()); err != nil {
-		log.Fatalf("❌ Cannot ping database: %v", err)
+// newPool applies the DB_* pool settings to dsn. Connections are opened lazily.
+func newPool(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
+	globalEnv := GetEnv()
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database configuration: %w", err)
 	}
+	poolConfig.MaxConns = globalEnv.DBMaxConns
+	poolConfig.MinConns = globalEnv.DBMinConns
+	poolConfig.MaxConnLifetime = globalEnv.DBMaxConnLifetime
+	poolConfig.MaxConnIdleTime = globalEnv.DBMaxConnIdleTime
+	poolConfig.HealthCheckPeriod = globalEnv.DBHealthCheckPeriod
+	// spans for every query, parented to the request span when the context carries one
+	poolConfig.ConnConfig.Tracer = otelpgx.NewTracer()
 
-	fmt.Println("✅ Connected to database")
-	return pool
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("creating database pool: %w", err)
+	}
+	return pool, nil
 }
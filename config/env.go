@@ -1,22 +1,152 @@
 package config
 
 import (
+	"fmt"
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 	"go.uber.org/zap"
 	"log"
+	"strings"
+	"time"
 )
 
 var globalEnv *Env
 
 type Env struct {
-	GinPort          string `envconfig:"GIN_PORT" default:"8000"`
+	// AppEnv is development, staging or production. Outside development the default
+	// secrets are refused (see Validate).
+	AppEnv  string `envconfig:"APP_ENV" default:"production"`
+	GinPort string `envconfig:"GIN_PORT" default:"8000"`
+
+	// DatabaseURL is a full connection string and takes precedence over the POSTGRES_*
+	// parts, e.g. postgres://user:pass@db:5432/app?sslmode=verify-full.
+	DatabaseURL      string `envconfig:"DATABASE_URL" default:"" secret:"true"`
 	PostgresHost     string `envconfig:"POSTGRES_HOST" default:""`
 	PostgresUser     string `envconfig:"POSTGRES_USER" default:""`
-	PostgresPassword string `envconfig:"POSTGRES_PASSWORD" default:""`
+	PostgresPassword string `envconfig:"POSTGRES_PASSWORD" default:"" secret:"true"`
 	PostgresDB       string `envconfig:"POSTGRES_DB" default:""`
-	PostgresPort     string `envconfig:"POSTGRES_PORT" default:""`
-	JWTSecret        string `envconfig:"JWT_SECRET" default:"supersecret"`
+	PostgresPort     string `envconfig:"POSTGRES_PORT" default:"5432"`
+	// PostgresSSLMode and PostgresSSLRootCert (a CA file) only apply to the POSTGRES_*
+	// parts; a DATABASE_URL carries its own sslmode.
+	PostgresSSLMode     string `envconfig:"POSTGRES_SSLMODE" default:"prefer"`
+	PostgresSSLRootCert string `envconfig:"POSTGRES_SSLROOTCERT" default:""`
+
+	// DBConnectTimeout is how long startup waits for the database to accept connections.
+	DBConnectTimeout    time.Duration `envconfig:"DB_CONNECT_TIMEOUT" default:"30s"`
+	DBMaxConns          int32         `envconfig:"DB_MAX_CONNS" default:"10"`
+	DBMinConns          int32         `envconfig:"DB_MIN_CONNS" default:"0"`
+	DBMaxConnLifetime   time.Duration `envconfig:"DB_MAX_CONN_LIFETIME" default:"1h"`
+	DBMaxConnIdleTime   time.Duration `envconfig:"DB_MAX_CONN_IDLE_TIME" default:"30m"`
+	DBHealthCheckPeriod time.Duration `envconfig:"DB_HEALTH_CHECK_PERIOD" default:"1m"`
+
+	// DatabaseReplicaURLs are streaming replicas that serve the heavy student reads (chat
+	// history, SCS mappings, similar questions). Replicas lagging more than DBReplicaMaxLag
+	// are skipped, and a user's reads stay on the primary for DBReadYourWritesWindow after
+	// they write.
+	DatabaseReplicaURLs    []string      `envconfig:"DATABASE_REPLICA_URLS" default:"" secret:"true"`
+	DBReplicaMaxLag        time.Duration `envconfig:"DB_REPLICA_MAX_LAG" default:"5s"`
+	DBReplicaCheckInterval time.Duration `envconfig:"DB_REPLICA_CHECK_INTERVAL" default:"2s"`
+	DBReadYourWritesWindow time.Duration `envconfig:"DB_READ_YOUR_WRITES_WINDOW" default:"10s"`
+
+	JWTSecret string `envconfig:"JWT_SECRET" default:"supersecret" secret:"true"`
+	// LegacyStudentTokensUntil accepts tokens without a role claim, issued before roles
+	// existed, as student tokens until this RFC 3339 time. Tokens live 24h, so a day after
+	// the roles release is enough; unset rejects them.
+	LegacyStudentTokensUntil time.Time `envconfig:"LEGACY_STUDENT_TOKENS_UNTIL"`
+	MigrateOnStartup         bool      `envconfig:"MIGRATE_ON_STARTUP" default:"false"`
+	LogLevel                 string    `envconfig:"LOG_LEVEL" default:"info"`
+	LogFormat                string    `envconfig:"LOG_FORMAT" default:"json"` // json or console
+
+	// TLSCertFile and TLSKeyFile switch the server to HTTPS and HTTP/2. The files are
+	// checked every TLSReloadInterval and reloaded when they change.
+	TLSCertFile       string        `envconfig:"TLS_CERT_FILE" default:""`
+	TLSKeyFile        string        `envconfig:"TLS_KEY_FILE" default:""`
+	TLSReloadInterval time.Duration `envconfig:"TLS_RELOAD_INTERVAL" default:"30s"`
+	// TLSClientCAFile makes /v1/admin require a client certificate signed by one of its CAs.
+	TLSClientCAFile string `envconfig:"TLS_CLIENT_CA_FILE" default:""`
+	// HTTPRedirectPort serves plain HTTP on this port, redirecting to HTTPS. TLS only.
+	HTTPRedirectPort string `envconfig:"HTTP_REDIRECT_PORT" default:""`
+
+	HTTPReadTimeout       time.Duration `envconfig:"HTTP_READ_TIMEOUT" default:"30s"`
+	HTTPReadHeaderTimeout time.Duration `envconfig:"HTTP_READ_HEADER_TIMEOUT" default:"10s"`
+	HTTPWriteTimeout      time.Duration `envconfig:"HTTP_WRITE_TIMEOUT" default:"60s"`
+	HTTPIdleTimeout       time.Duration `envconfig:"HTTP_IDLE_TIMEOUT" default:"120s"`
+	// ShutdownDrainPeriod is how long the server keeps serving after turning not-ready,
+	// giving load balancers time to stop sending new requests.
+	ShutdownDrainPeriod time.Duration `envconfig:"SHUTDOWN_DRAIN_PERIOD" default:"5s"`
+	ShutdownTimeout     time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"30s"`
+	// RequestTimeout bounds the work of a /v1 request, database queries included.
+	// Admin routes get AdminRequestTimeout instead since imports and rollovers run longer.
+	RequestTimeout      time.Duration `envconfig:"REQUEST_TIMEOUT" default:"10s"`
+	AdminRequestTimeout time.Duration `envconfig:"ADMIN_REQUEST_TIMEOUT" default:"50s"`
+
+	// CORSAllowedOrigins are the browser origins allowed to call the API: exact origins,
+	// patterns such as https://*.example.com, or "*". Empty rejects every cross-origin call.
+	CORSAllowedOrigins   []string `envconfig:"CORS_ALLOWED_ORIGINS" default:""`
+	CORSAllowCredentials bool     `envconfig:"CORS_ALLOW_CREDENTIALS" default:"false"`
+	CORSAllowedHeaders   []string `envconfig:"CORS_ALLOWED_HEADERS" default:"Authorization,Content-Type,Accept,X-Request-ID"`
+	CORSExposedHeaders   []string `envconfig:"CORS_EXPOSED_HEADERS" default:"X-Request-ID,Retry-After"`
+	// CORSMaxAge is how long browsers cache a preflight answer.
+	CORSMaxAge time.Duration `envconfig:"CORS_MAX_AGE" default:"10m"`
+	// CORSGroupOrigins replaces the allowed origins of single /v1 route groups, e.g.
+	// "admin=https://admin.example.com https://*.ops.example.com;public=*".
+	CORSGroupOrigins GroupOrigins `envconfig:"CORS_GROUP_ORIGINS" default:""`
+
+	// HealthCheckTimeout bounds each dependency probe of /readyz.
+	HealthCheckTimeout time.Duration `envconfig:"HEALTH_CHECK_TIMEOUT" default:"2s"`
+	MemcacheServers    []string      `envconfig:"MEMCACHE_SERVERS" default:""`
+
+	// Read-mostly queries are cached in memcache (when MEMCACHE_SERVERS is set) behind an
+	// in-process LRU of CacheLocalSize entries; 0 disables the LRU. Local copies live at
+	// most CacheLocalTTL, which bounds how stale another instance's write leaves them.
+	CacheLocalSize  int           `envconfig:"CACHE_LOCAL_SIZE" default:"10000"`
+	CacheLocalTTL   time.Duration `envconfig:"CACHE_LOCAL_TTL" default:"5s"`
+	CacheProfileTTL time.Duration `envconfig:"CACHE_PROFILE_TTL" default:"10m"`
+	CacheSCSTTL     time.Duration `envconfig:"CACHE_SCS_TTL" default:"5m"`
+	CacheNamesTTL   time.Duration `envconfig:"CACHE_NAMES_TTL" default:"1h"`
+
+	ServiceName       string  `envconfig:"OTEL_SERVICE_NAME" default:"buddhit-backend"`
+	TracesExporter    string  `envconfig:"OTEL_TRACES_EXPORTER" default:"none"` // none, otlp or stdout
+	TracesSampleRatio float64 `envconfig:"OTEL_TRACES_SAMPLE_RATIO" default:"1"`
+
+	AnswerEngineURL     string        `envconfig:"ANSWER_ENGINE_URL" default:""`
+	AnswerEngineTimeout time.Duration `envconfig:"ANSWER_ENGINE_TIMEOUT" default:"60s"`
+	AnswerQueueSize     int           `envconfig:"ANSWER_QUEUE_SIZE" default:"100"`
+	DuplicateMinScore   float64       `envconfig:"DUPLICATE_MIN_SCORE" default:"0.6"`
+	DuplicateLimit      int           `envconfig:"DUPLICATE_LIMIT" default:"3"`
+	EmbeddingDimensions int           `envconfig:"EMBEDDING_DIMENSIONS" default:"256"`
+
+	// ModerationBlocklist adds comma separated terms to the built-in profanity list for every school.
+	ModerationBlocklist []string `envconfig:"MODERATION_BLOCKLIST" default:""`
+
+	// ClassLadder is the default promotion order (class names or ids, lowest first) for rollovers.
+	ClassLadder []string `envconfig:"CLASS_LADDER" default:""`
+}
+
+// GroupOrigins maps a route group to its allowed origins. It is decoded from
+// "group=origin origin;group=origin", since origins contain ':' and may contain ','.
+type GroupOrigins map[string][]string
+
+func (g *GroupOrigins) Decode(value string) error {
+	groups := GroupOrigins{}
+	for _, item := range strings.Split(value, ";") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		group, origins, ok := strings.Cut(item, "=")
+		group = strings.TrimSpace(group)
+		if !ok || group == "" {
+			return fmt.Errorf("invalid group origins %q, expected group=origin origin", item)
+		}
+		groups[group] = strings.Fields(origins)
+	}
+	*g = groups
+	return nil
+}
+
+// TLSEnabled reports whether the server listens with HTTPS.
+func (e *Env) TLSEnabled() bool {
+	return e.TLSCertFile != "" && e.TLSKeyFile != ""
 }
 
 func LoadEnv() *Env {
@@ -6,13 +6,24 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateJWT(userID string, email string) (string, error) {
+const (
+	RoleStudent = "student"
+	RoleTeacher = "teacher"
+	RoleAdmin   = "admin"
+)
+
+func GenerateJWT(userID string, email string, role string) (string, error) {
+	return GenerateJWTWithTTL(userID, email, role, time.Hour*24) // expires in 24h
+}
+
+func GenerateJWTWithTTL(userID string, email string, role string, ttl time.Duration) (string, error) {
 	env := GetEnv()
 
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"email":   email,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(), // expires in 24h
+		"role":    role,
+		"exp":     time.Now().Add(ttl).Unix(),
 		"iat":     time.Now().Unix(),
 	}
 
@@ -1,13 +1,18 @@
 package config
 
 import (
+	"context"
+	"fmt"
 	"log"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 var logger *zap.Logger = nil
 
+type loggerKey struct{}
+
 func InitLogger() {
 	zapLogger, err := zap.NewProduction()
 	if err != nil {
@@ -17,6 +22,46 @@ func InitLogger() {
 	logger = zapLogger
 }
 
+// ConfigureLogger rebuilds the global logger once the environment is loaded.
+// format is "json" (default) or "console"; level is any zap level name.
+func ConfigureLogger(level string, format string) error {
+	parsedLevel, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid LOG_LEVEL %q: %w", level, err)
+	}
+
+	var cfg zap.Config
+	switch format {
+	case "json", "":
+		cfg = zap.NewProductionConfig()
+	case "console":
+		cfg = zap.NewDevelopmentConfig()
+	default:
+		return fmt.Errorf("invalid LOG_FORMAT %q, expected json or console", format)
+	}
+	cfg.Level = zap.NewAtomicLevelAt(parsedLevel)
+
+	zapLogger, err := cfg.Build()
+	if err != nil {
+		return err
+	}
+	logger = zapLogger
+	return nil
+}
+
 func GetLogger() *zap.Logger {
 	return logger
 }
+
+// WithLogger returns a copy of ctx carrying l, typically enriched with request fields.
+func WithLogger(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// LoggerFromContext returns the request scoped logger, falling back to the global one.
+func LoggerFromContext(ctx context.Context) *zap.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*zap.Logger); ok {
+		return l
+	}
+	return logger
+}
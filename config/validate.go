@@ -0,0 +1,222 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AppEnvDevelopment = "development"
+	AppEnvStaging     = "staging"
+	AppEnvProduction  = "production"
+
+	defaultJWTSecret = "supersecret"
+	// MinJWTSecretLength is the shortest secret accepted outside development; HS256
+	// keys shorter than the 32 byte hash are easier to brute force.
+	MinJWTSecretLength = 32
+)
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// Development reports whether the service runs with APP_ENV=development.
+func (e *Env) Development() bool {
+	return e.AppEnv == AppEnvDevelopment
+}
+
+// Validate reports every invalid setting at once, so a deploy fails on startup with
+// the full list instead of on the first request that needs the setting.
+func (e *Env) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if !slices.Contains([]string{AppEnvDevelopment, AppEnvStaging, AppEnvProduction}, e.AppEnv) {
+		fail("APP_ENV %q must be development, staging or production", e.AppEnv)
+	}
+	if !validPort(e.GinPort) {
+		fail("GIN_PORT %q is not a port number", e.GinPort)
+	}
+
+	if e.DatabaseURL != "" {
+		if !validDatabaseURL(e.DatabaseURL) {
+			fail("DATABASE_URL must be a postgres:// URL with a host")
+		}
+	} else {
+		for _, part := range []struct{ name, value string }{
+			{"POSTGRES_HOST", e.PostgresHost}, {"POSTGRES_USER", e.PostgresUser}, {"POSTGRES_DB", e.PostgresDB},
+		} {
+			if part.value == "" {
+				fail("%s is required unless DATABASE_URL is set", part.name)
+			}
+		}
+		if !validPort(e.PostgresPort) {
+			fail("POSTGRES_PORT %q is not a port number", e.PostgresPort)
+		}
+		if !slices.Contains(sslModes, e.PostgresSSLMode) {
+			fail("POSTGRES_SSLMODE %q must be one of %s", e.PostgresSSLMode, strings.Join(sslModes, ", "))
+		}
+	}
+	if e.PostgresSSLRootCert != "" {
+		if _, err := os.Stat(e.PostgresSSLRootCert); err != nil {
+			fail("POSTGRES_SSLROOTCERT: %v", err)
+		}
+	}
+
+	for i, dsn := range e.DatabaseReplicaURLs {
+		if !validDatabaseURL(dsn) {
+			fail("DATABASE_REPLICA_URLS entry %d must be a postgres:// URL with a host", i+1)
+		}
+	}
+	// a shorter window lets a user read from a replica that has not seen their write yet
+	if len(e.DatabaseReplicaURLs) > 0 && e.DBReadYourWritesWindow < e.DBReplicaMaxLag {
+		fail("DB_READ_YOUR_WRITES_WINDOW must be at least DB_REPLICA_MAX_LAG (%s)", e.DBReplicaMaxLag)
+	}
+
+	if e.CacheLocalSize < 0 {
+		fail("CACHE_LOCAL_SIZE must not be negative")
+	}
+
+	if e.DBMaxConns < 1 {
+		fail("DB_MAX_CONNS must be at least 1")
+	}
+	if e.DBMinConns < 0 || e.DBMinConns > e.DBMaxConns {
+		fail("DB_MIN_CONNS must be between 0 and DB_MAX_CONNS (%d)", e.DBMaxConns)
+	}
+	for _, setting := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{"DB_CONNECT_TIMEOUT", e.DBConnectTimeout},
+		{"DB_MAX_CONN_LIFETIME", e.DBMaxConnLifetime},
+		{"DB_MAX_CONN_IDLE_TIME", e.DBMaxConnIdleTime},
+		{"DB_HEALTH_CHECK_PERIOD", e.DBHealthCheckPeriod},
+		{"DB_REPLICA_MAX_LAG", e.DBReplicaMaxLag},
+		{"DB_REPLICA_CHECK_INTERVAL", e.DBReplicaCheckInterval},
+		{"DB_READ_YOUR_WRITES_WINDOW", e.DBReadYourWritesWindow},
+		{"REQUEST_TIMEOUT", e.RequestTimeout},
+		{"ADMIN_REQUEST_TIMEOUT", e.AdminRequestTimeout},
+		{"SHUTDOWN_TIMEOUT", e.ShutdownTimeout},
+		{"CACHE_LOCAL_TTL", e.CacheLocalTTL},
+		{"CACHE_PROFILE_TTL", e.CacheProfileTTL},
+		{"CACHE_SCS_TTL", e.CacheSCSTTL},
+		{"CACHE_NAMES_TTL", e.CacheNamesTTL},
+	} {
+		if setting.d <= 0 {
+			fail("%s must be positive", setting.name)
+		}
+	}
+
+	if !e.Development() {
+		switch {
+		case e.JWTSecret == defaultJWTSecret:
+			fail("JWT_SECRET must be set outside development")
+		case len(e.JWTSecret) < MinJWTSecretLength:
+			fail("JWT_SECRET must be at least %d bytes outside development", MinJWTSecretLength)
+		}
+	}
+	if e.JWTSecret == "" {
+		fail("JWT_SECRET must not be empty")
+	}
+
+	if (e.TLSCertFile == "") != (e.TLSKeyFile == "") {
+		fail("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if e.TLSClientCAFile != "" && !e.TLSEnabled() {
+		fail("TLS_CLIENT_CA_FILE needs TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	if e.HTTPRedirectPort != "" && (!e.TLSEnabled() || !validPort(e.HTTPRedirectPort) || e.HTTPRedirectPort == e.GinPort) {
+		fail("HTTP_REDIRECT_PORT needs TLS and a port other than GIN_PORT")
+	}
+
+	if !slices.Contains([]string{"none", "otlp", "stdout"}, e.TracesExporter) {
+		fail("OTEL_TRACES_EXPORTER %q must be none, otlp or stdout", e.TracesExporter)
+	}
+	if e.TracesSampleRatio < 0 || e.TracesSampleRatio > 1 {
+		fail("OTEL_TRACES_SAMPLE_RATIO must be between 0 and 1")
+	}
+	return errors.Join(errs...)
+}
+
+// validDatabaseURL checks the URL without reporting why; the parse error would echo the password.
+func validDatabaseURL(dsn string) bool {
+	u, err := url.Parse(dsn)
+	return err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") && u.Host != ""
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n < 65536
+}
+
+// DatabaseDSN is DATABASE_URL, or a URL built from the POSTGRES_* parts.
+func (e *Env) DatabaseDSN() string {
+	if e.DatabaseURL != "" {
+		return e.DatabaseURL
+	}
+	query := url.Values{"sslmode": {e.PostgresSSLMode}}
+	if e.PostgresSSLRootCert != "" {
+		query.Set("sslrootcert", e.PostgresSSLRootCert)
+	}
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(e.PostgresUser, e.PostgresPassword),
+		Host:     e.PostgresHost + ":" + e.PostgresPort,
+		Path:     "/" + e.PostgresDB,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+const redacted = "[redacted]"
+
+// Redacted is the effective configuration keyed by variable name, with the fields
+// tagged secret:"true" masked. Passwords inside DATABASE_URL are masked too.
+func (e *Env) Redacted() map[string]any {
+	values := map[string]any{}
+	v := reflect.ValueOf(e).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := field.Tag.Get("envconfig")
+		value := v.Field(i).Interface()
+		switch {
+		case field.Tag.Get("secret") != "true":
+			if d, ok := value.(time.Duration); ok {
+				value = d.String()
+			}
+		case v.Field(i).IsZero():
+			// empty shows that the secret is unset
+		case name == "DATABASE_URL":
+			value = redactURL(e.DatabaseURL)
+		case name == "DATABASE_REPLICA_URLS":
+			urls := make([]string, len(e.DatabaseReplicaURLs))
+			for i, dsn := range e.DatabaseReplicaURLs {
+				urls[i] = redactURL(dsn)
+			}
+			value = urls
+		default:
+			value = redacted
+		}
+		values[name] = value
+	}
+	return values
+}
+
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redacted
+	}
+	if query := u.Query(); query.Has("password") {
+		query.Set("password", redacted)
+		u.RawQuery = query.Encode()
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,10 @@
+package config
+
+// Build information, injected at build time:
+//
+//	go build -ldflags "-X backend/config.Version=v1.2.3 -X backend/config.Commit=$(git rev-parse HEAD) -X backend/config.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
@@ -0,0 +1,402 @@
+package controllers
+
+import (
+	"backend/apperror"
+	"backend/config"
+	"backend/handlers"
+	"backend/metrics"
+	"backend/models"
+	"backend/repository"
+	"backend/rollover"
+	"backend/roster"
+	"backend/validation"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type AdminController struct {
+	Accounts  repository.Accounts
+	Admin     repository.Admin
+	Roster    repository.RosterImporter
+	Rollovers repository.Rollovers
+}
+
+func (c *AdminController) Login(ctx *gin.Context) {
+	var req models.StudentLoginRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	account, err := c.Accounts.Authenticate(ctx.Request.Context(), config.RoleAdmin, req.Email, req.Password)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		respondError(ctx, err, "failed to log in")
+		return
+	}
+	if err != nil {
+		metrics.ObserveLogin(config.RoleAdmin, false)
+		apperror.Respond(ctx, apperror.Unauthorized("invalid email or password"))
+		return
+	}
+	metrics.ObserveLogin(config.RoleAdmin, true)
+
+	token, err := config.GenerateJWT(account.ID, req.Email, config.RoleAdmin)
+	if err != nil {
+		respondError(ctx, err, "could not generate token")
+		return
+	}
+
+	respond(ctx, http.StatusOK, models.LoginResponse{
+		Token: token,
+		User:  models.LoginUser{ID: account.ID, Name: account.FullName, Email: req.Email},
+	})
+}
+
+// ------------------
+// Schools, classes and subjects
+// ------------------
+
+func (c *AdminController) ListNamed(t handlers.NamedTable) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		entities, err := c.Admin.FetchNamed(ctx.Request.Context(), t)
+		if err != nil {
+			respondError(ctx, err, "failed to fetch "+t.Table)
+			return
+		}
+		respond(ctx, http.StatusOK, entities)
+	}
+}
+
+func (c *AdminController) GetNamed(t handlers.NamedTable) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		entity, err := c.Admin.FetchNamedByID(ctx.Request.Context(), t, ctx.Param("id"))
+		if errors.Is(err, pgx.ErrNoRows) {
+			apperror.Respond(ctx, apperror.NotFound(t.Label+" not found"))
+			return
+		}
+		if err != nil {
+			respondError(ctx, err, "failed to fetch "+t.Label)
+			return
+		}
+		respond(ctx, http.StatusOK, entity)
+	}
+}
+
+func (c *AdminController) CreateNamed(t handlers.NamedTable) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req models.NamedEntityRequest
+		if !bindJSON(ctx, &req) {
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+
+		entity, err := c.Admin.CreateNamed(ctx.Request.Context(), t, req.Name)
+		if handlers.IsUniqueViolation(err) {
+			apperror.Respond(ctx, apperror.Conflict(t.Label+" already exists"))
+			return
+		}
+		if err != nil {
+			respondError(ctx, err, "failed to create "+t.Label)
+			return
+		}
+		respond(ctx, http.StatusCreated, entity)
+	}
+}
+
+func (c *AdminController) UpdateNamed(t handlers.NamedTable) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req models.NamedEntityRequest
+		if !bindJSON(ctx, &req) {
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+
+		entity, err := c.Admin.UpdateNamed(ctx.Request.Context(), t, ctx.Param("id"), req.Name)
+		if errors.Is(err, pgx.ErrNoRows) {
+			apperror.Respond(ctx, apperror.NotFound(t.Label+" not found"))
+			return
+		}
+		if handlers.IsUniqueViolation(err) {
+			apperror.Respond(ctx, apperror.Conflict(t.Label+" already exists"))
+			return
+		}
+		if err != nil {
+			respondError(ctx, err, "failed to update "+t.Label)
+			return
+		}
+		respond(ctx, http.StatusOK, entity)
+	}
+}
+
+func (c *AdminController) DeleteNamed(t handlers.NamedTable) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		err := c.Admin.DeleteNamed(ctx.Request.Context(), t, ctx.Param("id"))
+		if errors.Is(err, pgx.ErrNoRows) {
+			apperror.Respond(ctx, apperror.NotFound(t.Label+" not found"))
+			return
+		}
+		if handlers.IsForeignKeyViolation(err) {
+			apperror.Respond(ctx, apperror.Conflict(t.Label+" is still used by a mapping"))
+			return
+		}
+		if err != nil {
+			respondError(ctx, err, "failed to delete "+t.Label)
+			return
+		}
+		respond(ctx, http.StatusOK, models.IDResponse{ID: ctx.Param("id")})
+	}
+}
+
+// ------------------
+// School/class/subject mappings
+// ------------------
+
+func (c *AdminController) ListSCSMappings(ctx *gin.Context) {
+	year := 0
+	if raw := ctx.Query("year"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			apperror.Respond(ctx, invalid("invalid query", []validation.FieldError{{Field: "year", Reason: "must be a number"}}))
+			return
+		}
+		year = parsed
+	}
+
+	mappings, err := c.Admin.FetchSCSMappings(ctx.Request.Context(), ctx.Query("school_id"), year)
+	if err != nil {
+		respondError(ctx, err, "failed to fetch mappings")
+		return
+	}
+	respond(ctx, http.StatusOK, mappings)
+}
+
+func (c *AdminController) GetSCSMapping(ctx *gin.Context) {
+	mapping, err := c.Admin.FetchSCSMappingByID(ctx.Request.Context(), ctx.Param("id"))
+	if errors.Is(err, pgx.ErrNoRows) {
+		apperror.Respond(ctx, apperror.NotFound("mapping not found"))
+		return
+	}
+	if err != nil {
+		respondError(ctx, err, "failed to fetch mapping")
+		return
+	}
+	respond(ctx, http.StatusOK, mapping)
+}
+
+func (c *AdminController) CreateSCSMapping(ctx *gin.Context) {
+	var req models.SCSMappingRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	mapping, err := c.Admin.CreateSCSMapping(ctx.Request.Context(), req)
+	if handlers.IsUniqueViolation(err) {
+		apperror.Respond(ctx, apperror.Conflict("mapping for this school, class, subject and year already exists"))
+		return
+	}
+	if handlers.IsForeignKeyViolation(err) {
+		apperror.Respond(ctx, apperror.Validation("school, class or subject does not exist"))
+		return
+	}
+	if err != nil {
+		respondError(ctx, err, "failed to create mapping")
+		return
+	}
+	respond(ctx, http.StatusCreated, mapping)
+}
+
+func (c *AdminController) UpdateSCSMapping(ctx *gin.Context) {
+	var req models.SCSMappingRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	mapping, err := c.Admin.UpdateSCSMapping(ctx.Request.Context(), ctx.Param("id"), req)
+	if errors.Is(err, pgx.ErrNoRows) {
+		apperror.Respond(ctx, apperror.NotFound("mapping not found"))
+		return
+	}
+	if handlers.IsUniqueViolation(err) {
+		apperror.Respond(ctx, apperror.Conflict("mapping for this school, class, subject and year already exists"))
+		return
+	}
+	if handlers.IsForeignKeyViolation(err) {
+		apperror.Respond(ctx, apperror.Validation("school, class or subject does not exist"))
+		return
+	}
+	if err != nil {
+		respondError(ctx, err, "failed to update mapping")
+		return
+	}
+	respond(ctx, http.StatusOK, mapping)
+}
+
+func (c *AdminController) DeleteSCSMapping(ctx *gin.Context) {
+	err := c.Admin.DeleteSCSMapping(ctx.Request.Context(), ctx.Param("id"))
+	if errors.Is(err, pgx.ErrNoRows) {
+		apperror.Respond(ctx, apperror.NotFound("mapping not found"))
+		return
+	}
+	if handlers.IsForeignKeyViolation(err) {
+		apperror.Respond(ctx, apperror.Conflict("mapping still has students or chats"))
+		return
+	}
+	if err != nil {
+		respondError(ctx, err, "failed to delete mapping")
+		return
+	}
+	respond(ctx, http.StatusOK, models.IDResponse{ID: ctx.Param("id")})
+}
+
+// ------------------
+// Enrollment
+// ------------------
+
+func (c *AdminController) ListEnrollments(ctx *gin.Context) {
+	enrollments, err := c.Admin.FetchEnrollments(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		respondError(ctx, err, "failed to fetch enrollments")
+		return
+	}
+	respond(ctx, http.StatusOK, enrollments)
+}
+
+func (c *AdminController) EnrollStudent(ctx *gin.Context) {
+	var req models.EnrollmentRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	enrollment, err := c.Admin.EnrollStudent(ctx.Request.Context(), ctx.Param("id"), req.StudentID)
+	if handlers.IsForeignKeyViolation(err) {
+		apperror.Respond(ctx, apperror.NotFound("student or mapping not found"))
+		return
+	}
+	if err != nil {
+		respondError(ctx, err, "failed to enroll student")
+		return
+	}
+	respond(ctx, http.StatusOK, enrollment)
+}
+
+func (c *AdminController) UnenrollStudent(ctx *gin.Context) {
+	enrollment, err := c.Admin.UnenrollStudent(ctx.Request.Context(), ctx.Param("id"), ctx.Param("student_id"))
+	if errors.Is(err, pgx.ErrNoRows) {
+		apperror.Respond(ctx, apperror.NotFound("enrollment not found"))
+		return
+	}
+	if err != nil {
+		respondError(ctx, err, "failed to unenroll student")
+		return
+	}
+	respond(ctx, http.StatusOK, enrollment)
+}
+
+// ------------------
+// Roster import
+// ------------------
+
+// ImportRoster validates an uploaded CSV/XLSX roster. It only writes when called with dry_run=false.
+func (c *AdminController) ImportRoster(ctx *gin.Context) {
+	dryRun := ctx.DefaultQuery("dry_run", "true") != "false"
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		apperror.Respond(ctx, apperror.Validation("file required"))
+		return
+	}
+	format := ctx.Query("format")
+	if format == "" {
+		format, err = roster.FormatFromFilename(file.Filename)
+		if err != nil {
+			apperror.Respond(ctx, apperror.Validation(err.Error()))
+			return
+		}
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		apperror.Respond(ctx, apperror.Validation("failed to read file"))
+		return
+	}
+	defer reader.Close()
+
+	rows, err := roster.Parse(reader, format)
+	if err != nil {
+		apperror.Respond(ctx, apperror.Validation(err.Error()))
+		return
+	}
+
+	report, err := c.Roster.Import(ctx.Request.Context(), rows, dryRun)
+	if errors.Is(err, roster.ErrInvalidRoster) {
+		apperror.Respond(ctx, apperror.Unprocessable(err.Error()).WithDetails(report))
+		return
+	}
+	if err != nil {
+		respondError(ctx, err, "failed to import roster")
+		return
+	}
+	respond(ctx, http.StatusOK, report)
+}
+
+// ------------------
+// Academic year rollover
+// ------------------
+
+// Rollover promotes a school to the next academic year. Like the roster import it
+// defaults to a dry run and only writes when dry_run is false.
+func (c *AdminController) Rollover(ctx *gin.Context) {
+	var req models.RolloverRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+	if len(req.Ladder) == 0 {
+		req.Ladder = config.GetEnv().ClassLadder
+	}
+	dryRun := req.DryRun == nil || *req.DryRun
+
+	diff, err := c.Rollovers.Run(ctx.Request.Context(), ctx.Param("id"), req.FromYear, req.Ladder, ctx.GetString("user_id"), dryRun)
+	if errors.Is(err, rollover.ErrNothingToRollOver) {
+		apperror.Respond(ctx, apperror.NotFound(err.Error()))
+		return
+	}
+	if errors.Is(err, rollover.ErrEmptyLadder) || errors.Is(err, rollover.ErrInvalidLadder) {
+		apperror.Respond(ctx, apperror.Validation(err.Error()))
+		return
+	}
+	if err != nil {
+		respondError(ctx, err, "rollover failed")
+		return
+	}
+	respond(ctx, http.StatusOK, diff)
+}
+
+func (c *AdminController) ListRollovers(ctx *gin.Context) {
+	runs, err := c.Admin.FetchRolloverRuns(ctx.Request.Context(), ctx.Query("school_id"))
+	if err != nil {
+		respondError(ctx, err, "failed to fetch rollovers")
+		return
+	}
+	respond(ctx, http.StatusOK, runs)
+}
+
+func (c *AdminController) UndoRollover(ctx *gin.Context) {
+	run, err := c.Rollovers.Undo(ctx.Request.Context(), ctx.Param("id"))
+	if errors.Is(err, pgx.ErrNoRows) {
+		apperror.Respond(ctx, apperror.NotFound("rollover not found"))
+		return
+	}
+	if errors.Is(err, rollover.ErrAlreadyUndone) || errors.Is(err, rollover.ErrNotLatestRun) || errors.Is(err, rollover.ErrNotUndoable) {
+		apperror.Respond(ctx, apperror.Conflict(err.Error()))
+		return
+	}
+	if err != nil {
+		respondError(ctx, err, "failed to undo rollover")
+		return
+	}
+	respond(ctx, http.StatusOK, run)
+}
@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"backend/apperror"
+	"backend/config"
+	"backend/models"
+	"backend/repository"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// AuthController serves the token endpoints shared by every role.
+type AuthController struct {
+	Accounts repository.Accounts
+}
+
+// Refresh exchanges a valid token for one with the same claims and a new expiry, so
+// clients stay logged in without keeping the password around. Accounts that were
+// disabled or deleted since the token was issued get no new token.
+func (c *AuthController) Refresh(ctx *gin.Context) {
+	role := ctx.GetString("role")
+	if role == "" {
+		apperror.Respond(ctx, apperror.Unauthorized("token has no role"))
+		return
+	}
+	account, err := c.Accounts.FetchUserByID(ctx.Request.Context(), role, ctx.GetString("user_id"))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		respondError(ctx, err, "could not refresh token")
+		return
+	}
+	if err != nil || account.DisabledAt != nil {
+		apperror.Respond(ctx, apperror.Unauthorized("account is disabled or no longer exists"))
+		return
+	}
+
+	token, err := config.GenerateJWT(account.ID, account.Email, role)
+	if err != nil {
+		respondError(ctx, err, "could not generate token")
+		return
+	}
+	respond(ctx, http.StatusOK, models.TokenResponse{Token: token})
+}
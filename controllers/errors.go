@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"backend/apperror"
+	"backend/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respond writes the success envelope shared by every /v1 endpoint.
+func respond(ctx *gin.Context, status int, data any) {
+	ctx.JSON(status, gin.H{
+		"status": true,
+		"data":   data,
+	})
+}
+
+// respondError answers with the error envelope. Unexpected errors are reported as
+// message; pgx.ErrNoRows and expired deadlines map to 404 and 504 (see apperror.From).
+func respondError(ctx *gin.Context, err error, message string) {
+	apperror.Respond(ctx, apperror.Internal(err, message))
+}
+
+// bindJSON decodes and validates the body into req using its binding tags. On failure
+// it answers 400 listing every invalid field and returns false.
+func bindJSON(ctx *gin.Context, req any) bool {
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		apperror.Respond(ctx, invalid("invalid request body", validation.Fields(err)).WithCause(err))
+		return false
+	}
+	return true
+}
+
+func invalid(message string, fields []validation.FieldError) *apperror.Error {
+	return apperror.Validation(message).WithDetails(fields)
+}
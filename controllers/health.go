@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/health"
+	"backend/lifecycle"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	Ready   *lifecycle.Readiness
+	Checks  []health.Check
+	Timeout time.Duration
+}
+
+// Liveness only proves the process can serve requests; it never checks dependencies
+// so a database outage does not get every replica restarted.
+func (c *HealthController) Liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+func (c *HealthController) Readiness(ctx *gin.Context) {
+	if c.Ready == nil || !c.Ready.Ready() {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "not_ready",
+			"reason": "starting or draining",
+		})
+		return
+	}
+
+	healthy, results := health.RunChecks(ctx.Request.Context(), c.Timeout, c.Checks)
+	if !healthy {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "not_ready",
+			"checks": results,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "ready",
+		"checks": results,
+	})
+}
+
+func (c *HealthController) Version(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"version":    config.Version,
+		"commit":     config.Commit,
+		"build_time": config.BuildTime,
+		"go_version": runtime.Version(),
+	})
+}
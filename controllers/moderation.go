@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"backend/answer"
+	"backend/apperror"
+	"backend/config"
+	"backend/models"
+	"backend/repository"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type ModerationController struct {
+	Moderation repository.Moderation
+	Answers    *answer.Worker
+}
+
+func (c *ModerationController) GetQueue(ctx *gin.Context) {
+	userID := ctx.GetString("user_id")
+	if userID == "" {
+		apperror.Respond(ctx, apperror.Unauthorized("user_id not found"))
+		return
+	}
+
+	messages, err := c.Moderation.FetchFlaggedMessages(ctx.Request.Context(), userID)
+	if err != nil {
+		respondError(ctx, err, "failed to fetch flagged messages")
+		return
+	}
+	respond(ctx, http.StatusOK, messages)
+}
+
+func (c *ModerationController) Review(ctx *gin.Context) {
+	id := ctx.Param("id")
+	userID := ctx.GetString("user_id")
+	if userID == "" {
+		apperror.Respond(ctx, apperror.Unauthorized("user_id not found"))
+		return
+	}
+
+	var req models.ModerationReviewRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	status := models.ModerationApproved
+	if req.Decision == "reject" {
+		status = models.ModerationRejected
+	}
+
+	message, err := c.Moderation.ReviewMessage(ctx.Request.Context(), userID, id, status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		apperror.Respond(ctx, apperror.NotFound("flagged message not found"))
+		return
+	}
+	if err != nil {
+		respondError(ctx, err, "failed to review message")
+		return
+	}
+
+	// approved questions continue to the answering engine
+	if status == models.ModerationApproved && message.Answer == nil && c.Answers != nil {
+		q := answer.Question{
+			MessageID:   message.ID,
+			ChatID:      message.ChatID,
+			Text:        message.Question,
+			SpanContext: trace.SpanContextFromContext(ctx.Request.Context()),
+		}
+		if message.ScsID != nil {
+			q.ScsID = *message.ScsID
+		}
+		if err := c.Answers.Enqueue(q); err != nil {
+			config.LoggerFromContext(ctx.Request.Context()).Warn("answer_enqueue_failed", zap.String("message_id", message.ID), zap.Error(err))
+		}
+	}
+
+	respond(ctx, http.StatusOK, message)
+}
+
+func (c *ModerationController) GetBlocklist(ctx *gin.Context) {
+	userID := ctx.GetString("user_id")
+	if userID == "" {
+		apperror.Respond(ctx, apperror.Unauthorized("user_id not found"))
+		return
+	}
+
+	terms, err := c.Moderation.FetchBlocklist(ctx.Request.Context(), userID)
+	if err != nil {
+		respondError(ctx, err, "failed to fetch blocklist")
+		return
+	}
+	respond(ctx, http.StatusOK, terms)
+}
+
+func (c *ModerationController) AddBlocklistTerm(ctx *gin.Context) {
+	userID := ctx.GetString("user_id")
+	if userID == "" {
+		apperror.Respond(ctx, apperror.Unauthorized("user_id not found"))
+		return
+	}
+
+	var req models.BlocklistTermRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+	req.Term = strings.TrimSpace(req.Term)
+
+	term, err := c.Moderation.AddBlocklistTerm(ctx.Request.Context(), userID, req.Term)
+	if errors.Is(err, pgx.ErrNoRows) {
+		apperror.Respond(ctx, apperror.Forbidden("teacher is not linked to a school"))
+		return
+	}
+	if err != nil {
+		respondError(ctx, err, "failed to add blocklist term")
+		return
+	}
+	respond(ctx, http.StatusCreated, term)
+}
+
+func (c *ModerationController) DeleteBlocklistTerm(ctx *gin.Context) {
+	id := ctx.Param("id")
+	userID := ctx.GetString("user_id")
+	if userID == "" {
+		apperror.Respond(ctx, apperror.Unauthorized("user_id not found"))
+		return
+	}
+
+	err := c.Moderation.DeleteBlocklistTerm(ctx.Request.Context(), userID, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		apperror.Respond(ctx, apperror.NotFound("blocklist term not found"))
+		return
+	}
+	if err != nil {
+		respondError(ctx, err, "failed to delete blocklist term")
+		return
+	}
+	respond(ctx, http.StatusOK, models.IDResponse{ID: id})
+}
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"backend/apperror"
+	"backend/models"
+	"backend/validation"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pageQuery reads the optional limit and offset query parameters of a list. On invalid
+// values it answers 400 listing them and returns false.
+func pageQuery(ctx *gin.Context) (models.Page, bool) {
+	var page models.Page
+	var fields []validation.FieldError
+	if raw := ctx.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		switch {
+		case err != nil:
+			fields = append(fields, validation.FieldError{Field: "limit", Reason: "must be a number"})
+		case limit < 1 || limit > models.MaxPageLimit:
+			fields = append(fields, validation.FieldError{Field: "limit", Reason: "must be between 1 and " + strconv.Itoa(models.MaxPageLimit)})
+		}
+		page.Limit = limit
+	}
+	if raw := ctx.Query("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		switch {
+		case err != nil:
+			fields = append(fields, validation.FieldError{Field: "offset", Reason: "must be a number"})
+		case offset < 0:
+			fields = append(fields, validation.FieldError{Field: "offset", Reason: "must be at least 0"})
+		}
+		page.Offset = offset
+	}
+
+	if len(fields) > 0 {
+		apperror.Respond(ctx, invalid("invalid query", fields))
+		return models.Page{}, false
+	}
+	return page, true
+}
@@ -1,136 +1,123 @@
 package controllers
 
 import (
+	"backend/answer"
+	"backend/apperror"
 	"backend/config"
-	"backend/handlers"
+	"backend/metrics"
 	"backend/models"
-	"context"
+	"backend/moderation"
+	"backend/repository"
+	"backend/similarity"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
 )
 
 type StudentController struct {
-	DB *pgxpool.Pool
+	Accounts   repository.Accounts
+	Students   repository.Students
+	Chats      repository.Chats
+	Messages   repository.Messages
+	SCS        repository.SCS
+	Moderation repository.Moderation
+	Detector   *similarity.Detector
+	Answers    *answer.Worker
+	Moderator  *moderation.Moderator
 }
 
 func (c *StudentController) Login(ctx *gin.Context) {
 	var req models.StudentLoginRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(ctx, &req) {
 		return
 	}
 
-	var id string
-	var fullName string
-	var password string
-
-	err := c.DB.QueryRow(
-		context.Background(),
-		"SELECT id, full_name, password FROM students WHERE email=$1 AND password=$2",
-		req.Email,
-		req.Password,
-	).Scan(&id, &fullName, &password)
+	account, err := c.Accounts.Authenticate(ctx.Request.Context(), config.RoleStudent, req.Email, req.Password)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		respondError(ctx, err, "failed to log in")
+		return
+	}
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+		metrics.ObserveLogin(config.RoleStudent, false)
+		apperror.Respond(ctx, apperror.Unauthorized("invalid email or password"))
 		return
 	}
+	metrics.ObserveLogin(config.RoleStudent, true)
 
 	// ✅ Generate JWT
-	token, err := config.GenerateJWT(id, req.Email)
+	token, err := config.GenerateJWT(account.ID, req.Email, config.RoleStudent)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "could not generate token"})
+		respondError(ctx, err, "could not generate token")
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"message": "login successful",
-		"token":   token,
-		"user": gin.H{
-			"id":    id,
-			"name":  fullName,
-			"email": req.Email,
-		},
+	respond(ctx, http.StatusOK, models.LoginResponse{
+		Token: token,
+		User:  models.LoginUser{ID: account.ID, Name: account.FullName, Email: req.Email},
 	})
 }
 
 func (c *StudentController) ResetPassword(ctx *gin.Context) {
 	var req models.StudentResetPasswordRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+	if !bindJSON(ctx, &req) {
 		return
 	}
 
-	userID, _ := ctx.Get("user_id")
+	userID := ctx.GetString("user_id")
 
-	if req.Password == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "password required"})
+	err := c.Accounts.SetPassword(ctx.Request.Context(), config.RoleStudent, userID, req.Password)
+	if errors.Is(err, pgx.ErrNoRows) {
+		apperror.Respond(ctx, apperror.NotFound("student not found"))
 		return
 	}
-
-	commandTag, err := c.DB.Exec(
-		context.Background(),
-		"UPDATE students SET password=$1 WHERE id=$2",
-		req.Password,
-		userID,
-	)
-
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update student password"})
+		respondError(ctx, err, "failed to update student password")
 		return
 	}
 
-	if commandTag.RowsAffected() == 0 {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "student email not found"})
-		return
-	}
-
-	ctx.JSON(http.StatusOK, gin.H{
-		"status":  true,
-		"message": "student password reset succesfully",
-	})
+	respond(ctx, http.StatusOK, models.IDResponse{ID: userID})
 }
 
 func (c *StudentController) GetDetails(ctx *gin.Context) {
 	// Get user_id from Gin context
 	userID := ctx.GetString("user_id")
 	if userID == "" {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found"})
+		apperror.Respond(ctx, apperror.Unauthorized("user_id not found"))
 		return
 	}
 
-	studentHandler := handlers.StudentHandler{DB: c.DB}
-	student, err := studentHandler.FetchStudentByID(userID)
+	student, err := c.Students.FetchStudentByID(ctx.Request.Context(), userID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch student"})
+		respondError(ctx, err, "failed to fetch student")
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"status": true,
-		"data":   student,
-	})
+	respond(ctx, http.StatusOK, student)
 	return
 }
 
 func (c *StudentController) GetChatList(ctx *gin.Context) {
 	userID := ctx.GetString("user_id")
 	if userID == "" {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found"})
+		apperror.Respond(ctx, apperror.Unauthorized("user_id not found"))
 		return
 	}
 
-	studentHandler := handlers.StudentHandler{DB: c.DB}
-	chatList, err := studentHandler.FetchChatList(userID)
+	page, ok := pageQuery(ctx)
+	if !ok {
+		return
+	}
+
+	chatList, err := c.Chats.FetchChatList(ctx.Request.Context(), userID, page)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch student"})
+		respondError(ctx, err, "failed to fetch chats")
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{
-		"status": true,
-		"data":   chatList,
-	})
+	respond(ctx, http.StatusOK, chatList)
 	return
 }
 
@@ -138,20 +125,20 @@ func (c *StudentController) GetChatDetailsByID(ctx *gin.Context) {
 	id := ctx.Param("id")
 	userID := ctx.GetString("user_id")
 	if userID == "" {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found"})
+		apperror.Respond(ctx, apperror.Unauthorized("user_id not found"))
 		return
 	}
 
-	studentHandler := handlers.StudentHandler{DB: c.DB}
-	chat, err := studentHandler.FetchChatDetailsByID(userID, id)
+	chat, err := c.Chats.FetchChatDetailsByID(ctx.Request.Context(), userID, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		apperror.Respond(ctx, apperror.NotFound("chat not found"))
+		return
+	}
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch chat details"})
+		respondError(ctx, err, "failed to fetch chat details")
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{
-		"status": true,
-		"data":   chat,
-	})
+	respond(ctx, http.StatusOK, chat)
 	return
 }
 
@@ -159,44 +146,148 @@ func (c *StudentController) GetChatMessages(ctx *gin.Context) {
 	id := ctx.Param("id")
 	userID := ctx.GetString("user_id")
 	if userID == "" {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found"})
+		apperror.Respond(ctx, apperror.Unauthorized("user_id not found"))
+		return
+	}
+	page, ok := pageQuery(ctx)
+	if !ok {
 		return
 	}
 
-	studentHandler := handlers.StudentHandler{DB: c.DB}
-	chat, err := studentHandler.FetchChatDetailsByID(userID, id)
-	if err != nil && chat.ID == "" {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch chat details"})
+	chat, err := c.Chats.FetchChatDetailsByID(ctx.Request.Context(), userID, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		apperror.Respond(ctx, apperror.NotFound("chat not found"))
 		return
 	}
-	chatMessages, err := studentHandler.FetchChatMessages(chat.ID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch student"})
+		respondError(ctx, err, "failed to fetch chat details")
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{
-		"status": true,
-		"data":   chatMessages,
-	})
+	chatMessages, err := c.Messages.FetchChatMessages(ctx.Request.Context(), chat.ID, page)
+	if err != nil {
+		respondError(ctx, err, "failed to fetch chat messages")
+		return
+	}
+	respond(ctx, http.StatusOK, chatMessages)
 	return
 }
 
 func (c *StudentController) GetSCSMapping(ctx *gin.Context) {
 	userID := ctx.GetString("user_id")
 	if userID == "" {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not found"})
+		apperror.Respond(ctx, apperror.Unauthorized("user_id not found"))
 		return
 	}
 
-	studentHandler := handlers.StudentHandler{DB: c.DB}
-	scsMapping, err := studentHandler.FetchSCSDetailsByUserID(userID)
+	scsMapping, err := c.SCS.FetchSCSDetailsByUserID(ctx.Request.Context(), userID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch chat details"})
+		respondError(ctx, err, "failed to fetch subjects")
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{
-		"status": true,
-		"data":   scsMapping,
-	})
+	respond(ctx, http.StatusOK, scsMapping)
 	return
 }
+
+func (c *StudentController) AskQuestion(ctx *gin.Context) {
+	id := ctx.Param("id")
+	userID := ctx.GetString("user_id")
+	if userID == "" {
+		apperror.Respond(ctx, apperror.Unauthorized("user_id not found"))
+		return
+	}
+
+	var req models.AskQuestionRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+	req.Question = strings.TrimSpace(req.Question)
+
+	chat, err := c.Chats.FetchChatDetailsByID(ctx.Request.Context(), userID, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		apperror.Respond(ctx, apperror.NotFound("chat not found"))
+		return
+	}
+	if err != nil {
+		respondError(ctx, err, "failed to fetch chat details")
+		return
+	}
+
+	// moderation runs before anything is stored or answered
+	verdict := models.ModerationVerdict{Status: models.ModerationApproved, Reasons: []string{}}
+	if c.Moderator != nil {
+		var schoolTerms []string
+		if chat.ScsID != nil {
+			schoolTerms, err = c.Moderation.FetchBlocklistTermsBySCS(ctx.Request.Context(), *chat.ScsID)
+			if err != nil {
+				respondError(ctx, err, "failed to load moderation rules")
+				return
+			}
+		}
+		result := c.Moderator.Check(req.Question, schoolTerms)
+		req.Question = result.Text
+		verdict = result.Verdict
+	}
+
+	if verdict.Status == models.ModerationFlagged {
+		message, err := c.Messages.CreateChatMessage(ctx.Request.Context(), chat.ID, req.Question, nil, verdict)
+		if err != nil {
+			respondError(ctx, err, "failed to store question")
+			return
+		}
+		config.LoggerFromContext(ctx.Request.Context()).Warn("question_flagged",
+			zap.String("message_id", message.ID),
+			zap.String("chat_id", chat.ID),
+			zap.Strings("reasons", verdict.Reasons),
+		)
+		respond(ctx, http.StatusCreated, models.AskQuestionResponse{Message: &message})
+		return
+	}
+
+	// the student accepted an existing answer instead of asking the engine again
+	if req.ReuseMessageID != "" {
+		if chat.ScsID == nil {
+			apperror.Respond(ctx, apperror.Validation("chat is not linked to a subject"))
+			return
+		}
+		original, err := c.Messages.FetchAnsweredMessageInSCS(ctx.Request.Context(), *chat.ScsID, req.ReuseMessageID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			respondError(ctx, err, "failed to fetch answered message")
+			return
+		}
+		if err != nil {
+			apperror.Respond(ctx, apperror.NotFound("answered message not found"))
+			return
+		}
+		message, err := c.Messages.CreateChatMessage(ctx.Request.Context(), chat.ID, req.Question, original.Answer, verdict)
+		if err != nil {
+			respondError(ctx, err, "failed to store question")
+			return
+		}
+		respond(ctx, http.StatusCreated, models.AskQuestionResponse{Message: &message})
+		return
+	}
+
+	if !req.Force && chat.ScsID != nil && c.Detector != nil {
+		matches, err := c.Detector.Find(ctx.Request.Context(), *chat.ScsID, req.Question)
+		if err != nil {
+			config.LoggerFromContext(ctx.Request.Context()).Warn("duplicate_lookup_failed", zap.String("chat_id", chat.ID), zap.Error(err))
+		} else if len(matches) > 0 {
+			respond(ctx, http.StatusOK, models.AskQuestionResponse{Duplicate: true, Matches: matches})
+			return
+		}
+	}
+
+	message, err := c.Messages.CreateChatMessage(ctx.Request.Context(), chat.ID, req.Question, nil, verdict)
+	if err != nil {
+		respondError(ctx, err, "failed to store question")
+		return
+	}
+
+	if c.Answers != nil {
+		if err := c.Answers.Enqueue(answer.QuestionFromMessage(ctx.Request.Context(), message, chat)); err != nil {
+			config.LoggerFromContext(ctx.Request.Context()).Warn("answer_enqueue_failed", zap.String("message_id", message.ID), zap.Error(err))
+		}
+	}
+
+	respond(ctx, http.StatusCreated, models.AskQuestionResponse{Message: &message})
+}
@@ -1,91 +1,69 @@
 package controllers
 
 import (
+	"backend/apperror"
 	"backend/config"
+	"backend/metrics"
 	"backend/models"
-	"context"
+	"backend/repository"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 )
 
 type TeacherController struct {
-	DB *pgxpool.Pool
+	Accounts repository.Accounts
 }
 
 func (c *TeacherController) Login(ctx *gin.Context) {
 	var req models.StudentLoginRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(ctx, &req) {
 		return
 	}
 
-	var id string
-	var fullName string
-	var password string
-
-	err := c.DB.QueryRow(
-		context.Background(),
-		"SELECT id, full_name, password FROM teachers WHERE email=$1 AND password=$2",
-		req.Email,
-		req.Password,
-	).Scan(&id, &fullName, &password)
+	account, err := c.Accounts.Authenticate(ctx.Request.Context(), config.RoleTeacher, req.Email, req.Password)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		respondError(ctx, err, "failed to log in")
+		return
+	}
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+		metrics.ObserveLogin(config.RoleTeacher, false)
+		apperror.Respond(ctx, apperror.Unauthorized("invalid email or password"))
 		return
 	}
+	metrics.ObserveLogin(config.RoleTeacher, true)
 
 	// ✅ Generate JWT
-	token, err := config.GenerateJWT(id, req.Email)
+	token, err := config.GenerateJWT(account.ID, req.Email, config.RoleTeacher)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "could not generate token"})
+		respondError(ctx, err, "could not generate token")
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"message": "login successful",
-		"token":   token,
-		"user": gin.H{
-			"id":    id,
-			"name":  fullName,
-			"email": req.Email,
-		},
+	respond(ctx, http.StatusOK, models.LoginResponse{
+		Token: token,
+		User:  models.LoginUser{ID: account.ID, Name: account.FullName, Email: req.Email},
 	})
 }
 
 func (c *TeacherController) ResetPassword(ctx *gin.Context) {
 	var req models.TeacherResetPasswordRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+	if !bindJSON(ctx, &req) {
 		return
 	}
-	userID, _ := ctx.Get("user_id")
+	userID := ctx.GetString("user_id")
 
-	if req.Password == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "email and password are required"})
+	err := c.Accounts.SetPassword(ctx.Request.Context(), config.RoleTeacher, userID, req.Password)
+	if errors.Is(err, pgx.ErrNoRows) {
+		apperror.Respond(ctx, apperror.NotFound("teacher not found"))
 		return
 	}
-
-	commandTag, err := c.DB.Exec(
-		context.Background(),
-		"UPDATE teachers SET password=$1 WHERE id=$2",
-		req.Password,
-		userID,
-	)
-
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update teacher password"})
+		respondError(ctx, err, "failed to update teacher password")
 		return
 	}
 
-	if commandTag.RowsAffected() == 0 {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "teacher email not found"})
-		return
-	}
-
-	ctx.JSON(http.StatusOK, gin.H{
-		"status":  true,
-		"message": "teacher password reset succesfully",
-	})
+	respond(ctx, http.StatusOK, models.IDResponse{ID: userID})
 }
@@ -1,38 +0,0 @@
-package db
-
-import (
-	"database/sql"
-	"fmt"
-	"log"
-
-	_ "github.com/lib/pq"
-)
-
-type Config struct {
-	DBHost     string
-	DBUser     string
-	DBPassword string
-	DBName     string
-	DBSSLMode  string
-}
-
-// Connect connects to PostgreSQL and pings immediately
-func Connect(cfg *Config) (*sql.DB, error) {
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s sslmode=%s",
-		cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBSSLMode,
-	)
-
-	log.Println("Connecting to PostgreSQL...")
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("sql.Open error: %w", err)
-	}
-
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("db.Ping error: %w", err)
-	}
-
-	log.Println("✅ Connected to PostgreSQL successfully")
-	return db, nil
-}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"backend/models"
+	"context"
+	"fmt"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AdminHandler struct {
+	DB *pgxpool.Pool
+}
+
+// NamedTable is a table of models.NamedEntity rows. Only the values below are
+// valid; the table name is interpolated into SQL.
+type NamedTable struct {
+	Table string
+	Label string
+}
+
+var (
+	SchoolsTable  = NamedTable{Table: "schools", Label: "school"}
+	ClassesTable  = NamedTable{Table: "classes", Label: "class"}
+	SubjectsTable = NamedTable{Table: "subjects", Label: "subject"}
+)
+
+func (c *AdminHandler) FetchNamed(ctx context.Context, t NamedTable) ([]models.NamedEntity, error) {
+	var entities []models.NamedEntity
+	query := fmt.Sprintf(`SELECT id, name, created_at, updated_at FROM %s ORDER BY name`, t.Table)
+	err := pgxscan.Select(ctx, c.DB, &entities, query)
+	if err != nil {
+		return []models.NamedEntity{}, err
+	}
+	return entities, nil
+}
+
+func (c *AdminHandler) FetchNamedByID(ctx context.Context, t NamedTable, id string) (models.NamedEntity, error) {
+	var entity models.NamedEntity
+	query := fmt.Sprintf(`SELECT id, name, created_at, updated_at FROM %s WHERE id=$1`, t.Table)
+	err := pgxscan.Get(ctx, c.DB, &entity, query, id)
+	if err != nil {
+		return models.NamedEntity{}, err
+	}
+	return entity, nil
+}
+
+func (c *AdminHandler) CreateNamed(ctx context.Context, t NamedTable, name string) (models.NamedEntity, error) {
+	var entity models.NamedEntity
+	query := fmt.Sprintf(`INSERT INTO %s (name) VALUES ($1) RETURNING id, name, created_at, updated_at`, t.Table)
+	err := pgxscan.Get(ctx, c.DB, &entity, query, name)
+	if err != nil {
+		return models.NamedEntity{}, err
+	}
+	return entity, nil
+}
+
+func (c *AdminHandler) UpdateNamed(ctx context.Context, t NamedTable, id string, name string) (models.NamedEntity, error) {
+	var entity models.NamedEntity
+	query := fmt.Sprintf(`UPDATE %s SET name=$2, updated_at=now() WHERE id=$1 RETURNING id, name, created_at, updated_at`, t.Table)
+	err := pgxscan.Get(ctx, c.DB, &entity, query, id, name)
+	if err != nil {
+		return models.NamedEntity{}, err
+	}
+	return entity, nil
+}
+
+func (c *AdminHandler) DeleteNamed(ctx context.Context, t NamedTable, id string) error {
+	commandTag, err := c.DB.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id=$1`, t.Table), id)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// FetchSCSMappings lists mappings, optionally filtered by school and year (zero values match all).
+func (c *AdminHandler) FetchSCSMappings(ctx context.Context, schoolID string, year int) ([]models.SCSMapping, error) {
+	var mappings []models.SCSMapping
+	query := `
+		SELECT * FROM school_class_subject_mapping
+		WHERE ($1 = '' OR school_id::text = $1) AND ($2 = 0 OR year = $2)
+		ORDER BY year, school_id, class_id, subject_id
+	`
+	err := pgxscan.Select(ctx, c.DB, &mappings, query, schoolID, year)
+	if err != nil {
+		return []models.SCSMapping{}, err
+	}
+	return mappings, nil
+}
+
+func (c *AdminHandler) FetchSCSMappingByID(ctx context.Context, id string) (models.SCSMapping, error) {
+	var mapping models.SCSMapping
+	query := `SELECT * FROM school_class_subject_mapping WHERE id=$1`
+	err := pgxscan.Get(ctx, c.DB, &mapping, query, id)
+	if err != nil {
+		return models.SCSMapping{}, err
+	}
+	return mapping, nil
+}
+
+// CreateSCSMapping fails with a unique violation when school+class+subject+year already exists.
+func (c *AdminHandler) CreateSCSMapping(ctx context.Context, req models.SCSMappingRequest) (models.SCSMapping, error) {
+	var mapping models.SCSMapping
+	query := `
+		INSERT INTO school_class_subject_mapping (school_id, class_id, subject_id, year)
+		VALUES ($1, $2, $3, $4)
+		RETURNING *
+	`
+	err := pgxscan.Get(ctx, c.DB, &mapping, query, req.SchoolID, req.ClassID, req.SubjectID, req.Year)
+	if err != nil {
+		return models.SCSMapping{}, err
+	}
+	return mapping, nil
+}
+
+func (c *AdminHandler) UpdateSCSMapping(ctx context.Context, id string, req models.SCSMappingRequest) (models.SCSMapping, error) {
+	var mapping models.SCSMapping
+	query := `
+		UPDATE school_class_subject_mapping
+		SET school_id=$2, class_id=$3, subject_id=$4, year=$5, updated_at=now()
+		WHERE id=$1
+		RETURNING *
+	`
+	err := pgxscan.Get(ctx, c.DB, &mapping, query, id, req.SchoolID, req.ClassID, req.SubjectID, req.Year)
+	if err != nil {
+		return models.SCSMapping{}, err
+	}
+	return mapping, nil
+}
+
+func (c *AdminHandler) DeleteSCSMapping(ctx context.Context, id string) error {
+	commandTag, err := c.DB.Exec(ctx, `DELETE FROM school_class_subject_mapping WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (c *AdminHandler) FetchEnrollments(ctx context.Context, scsID string) ([]models.StudentSCSMapping, error) {
+	var enrollments []models.StudentSCSMapping
+	query := `SELECT * FROM student_scs_mapping WHERE scs_id=$1 ORDER BY is_active DESC, created_at`
+	err := pgxscan.Select(ctx, c.DB, &enrollments, query, scsID)
+	if err != nil {
+		return []models.StudentSCSMapping{}, err
+	}
+	return enrollments, nil
+}
+
+// EnrollStudent activates the student's mapping, reactivating a previous enrollment if there is one.
+func (c *AdminHandler) EnrollStudent(ctx context.Context, scsID string, studentID string) (models.StudentSCSMapping, error) {
+	var enrollment models.StudentSCSMapping
+	query := `
+		INSERT INTO student_scs_mapping (student_id, scs_id, is_active)
+		VALUES ($1, $2, true)
+		ON CONFLICT (student_id, scs_id) DO UPDATE SET is_active = true, updated_at = now()
+		RETURNING *
+	`
+	err := pgxscan.Get(ctx, c.DB, &enrollment, query, studentID, scsID)
+	if err != nil {
+		return models.StudentSCSMapping{}, err
+	}
+	return enrollment, nil
+}
+
+// UnenrollStudent deactivates the mapping but keeps it, so past years stay visible to the student.
+func (c *AdminHandler) UnenrollStudent(ctx context.Context, scsID string, studentID string) (models.StudentSCSMapping, error) {
+	var enrollment models.StudentSCSMapping
+	query := `
+		UPDATE student_scs_mapping SET is_active = false, updated_at = now()
+		WHERE student_id=$1 AND scs_id=$2
+		RETURNING *
+	`
+	err := pgxscan.Get(ctx, c.DB, &enrollment, query, studentID, scsID)
+	if err != nil {
+		return models.StudentSCSMapping{}, err
+	}
+	return enrollment, nil
+}
+
+func (c *AdminHandler) FetchRolloverRuns(ctx context.Context, schoolID string) ([]models.RolloverRun, error) {
+	var runs []models.RolloverRun
+	query := `
+		SELECT * FROM rollover_runs
+		WHERE ($1 = '' OR school_id::text = $1)
+		ORDER BY created_at DESC
+	`
+	err := pgxscan.Select(ctx, c.DB, &runs, query, schoolID)
+	if err != nil {
+		return []models.RolloverRun{}, err
+	}
+	return runs, nil
+}
@@ -0,0 +1,262 @@
+package handlers_test
+
+import (
+	"backend/config"
+	"backend/handlers"
+	"backend/models"
+	"backend/pgtest"
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestMain(m *testing.M) { os.Exit(pgtest.Main(m)) }
+
+func TestFetchSCSDetailsByUserIDGroupsByYear(t *testing.T) {
+	h := &handlers.StudentHandler{DB: pgtest.Require(t, pgtest.FixtureSchool)}
+	ctx := context.Background()
+
+	years, err := h.FetchSCSDetailsByUserID(ctx, pgtest.AshaID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(years) != 2 {
+		t.Fatalf("got %+v, want 2024 and 2025", years)
+	}
+	want := []struct {
+		year     int
+		isActive bool
+		class    string
+		scsIDs   []string
+	}{
+		{2024, false, "Class 8", []string{pgtest.Maths2024SCSID, pgtest.Science2024SCSID}},
+		{2025, true, "Class 9", []string{pgtest.Maths2025SCSID, pgtest.Science2025SCSID}},
+	}
+	for i, w := range want {
+		got := years[i]
+		if got.Year != w.year || got.IsActive != w.isActive || len(got.Details) != 2 {
+			t.Fatalf("year %d: got %+v", w.year, got)
+		}
+		// details are ordered by subject name
+		for j, d := range got.Details {
+			if d.ScsID != w.scsIDs[j] || d.School != "Demo School" || d.Class != w.class {
+				t.Fatalf("year %d detail %d: got %+v", w.year, j, d)
+			}
+		}
+		if got.Details[0].Subject != "Mathematics" || got.Details[1].Subject != "Science" {
+			t.Fatalf("year %d: got subjects %q, %q", w.year, got.Details[0].Subject, got.Details[1].Subject)
+		}
+	}
+
+	none, err := h.FetchSCSDetailsByUserID(ctx, pgtest.KiranID)
+	if err != nil || len(none) != 0 {
+		t.Fatalf("student without enrollments: got %+v, %v", none, err)
+	}
+}
+
+func TestChatsAndMessages(t *testing.T) {
+	h := &handlers.StudentHandler{DB: pgtest.Require(t, pgtest.FixtureSchool)}
+	ctx := context.Background()
+
+	chats, err := h.FetchChatList(ctx, pgtest.AshaID, models.Page{})
+	if err != nil || len(chats) != 1 || chats[0].ID != pgtest.FractionsChatID {
+		t.Fatalf("got %+v, %v", chats, err)
+	}
+	if _, err := h.FetchChatDetailsByID(ctx, pgtest.KiranID, pgtest.FractionsChatID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("another student's chat: got %v, want pgx.ErrNoRows", err)
+	}
+
+	answer := "Three quarters."
+	created, err := h.CreateChatMessage(ctx, pgtest.FractionsChatID, "What is 1/2 + 1/4?", &answer, models.ModerationVerdict{Status: models.ModerationApproved, Reasons: []string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.AnsweredAt == nil || created.Answer == nil || *created.Answer != answer {
+		t.Fatalf("answered message: got %+v", created)
+	}
+
+	pending, err := h.CreateChatMessage(ctx, pgtest.FractionsChatID, "Why?", nil, models.ModerationVerdict{Status: models.ModerationApproved, Reasons: []string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending.AnsweredAt != nil || pending.Answer != nil {
+		t.Fatalf("pending message: got %+v", pending)
+	}
+	if err := h.SaveMessageAnswer(ctx, pending.ID, "Because."); err != nil {
+		t.Fatal(err)
+	}
+
+	messages, err := h.FetchChatMessages(ctx, pgtest.FractionsChatID, models.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 4 || messages[0].ID != pending.ID {
+		t.Fatalf("got %d messages, want 4 newest first", len(messages))
+	}
+	page, err := h.FetchChatMessages(ctx, pgtest.FractionsChatID, models.Page{Limit: 2, Offset: 1})
+	if err != nil || len(page) != 2 || page[0].ID != messages[1].ID || page[1].ID != messages[2].ID {
+		t.Fatalf("second and third message: got %+v, %v", page, err)
+	}
+	if messages[0].Answer == nil || *messages[0].Answer != "Because." || messages[0].AnsweredAt == nil {
+		t.Fatalf("saved answer: got %+v", messages[0])
+	}
+}
+
+func TestFetchSimilarQuestions(t *testing.T) {
+	h := &handlers.StudentHandler{DB: pgtest.Require(t, pgtest.FixtureSchool)}
+	ctx := context.Background()
+
+	matches, err := h.FetchSimilarQuestions(ctx, pgtest.Maths2025SCSID, "how to add fractions", 0.2, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the flagged question is never suggested
+	if len(matches) != 1 || matches[0].MessageID != pgtest.AnsweredMessageID || matches[0].Source != "trigram" {
+		t.Fatalf("got %+v", matches)
+	}
+
+	other, err := h.FetchSimilarQuestions(ctx, pgtest.Science2025SCSID, pgtest.AnsweredQuestion, 0.2, 5)
+	if err != nil || len(other) != 0 {
+		t.Fatalf("other SCS: got %+v, %v", other, err)
+	}
+
+	if _, err := h.FetchAnsweredMessageInSCS(ctx, pgtest.Science2025SCSID, pgtest.AnsweredMessageID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("message of another SCS: got %v, want pgx.ErrNoRows", err)
+	}
+	questions, err := h.FetchAnsweredQuestions(ctx)
+	if err != nil || len(questions) != 1 || questions[0].ScsID != pgtest.Maths2025SCSID {
+		t.Fatalf("got %+v, %v", questions, err)
+	}
+}
+
+func TestModerationScope(t *testing.T) {
+	h := &handlers.ModerationHandler{DB: pgtest.Require(t, pgtest.FixtureSchool)}
+	ctx := context.Background()
+
+	queue, err := h.FetchFlaggedMessages(ctx, pgtest.RaviID)
+	if err != nil || len(queue) != 1 || queue[0].ID != pgtest.FlaggedMessageID {
+		t.Fatalf("got %+v, %v", queue, err)
+	}
+	if queue[0].ScsID == nil || *queue[0].ScsID != pgtest.Maths2025SCSID {
+		t.Fatalf("got scs %v", queue[0].ScsID)
+	}
+
+	queue, err = h.FetchFlaggedMessages(ctx, pgtest.NilaID)
+	if err != nil || len(queue) != 0 {
+		t.Fatalf("teacher of another school: got %+v, %v", queue, err)
+	}
+	if _, err := h.ReviewMessage(ctx, pgtest.NilaID, pgtest.FlaggedMessageID, models.ModerationApproved); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("review out of scope: got %v, want pgx.ErrNoRows", err)
+	}
+
+	reviewed, err := h.ReviewMessage(ctx, pgtest.RaviID, pgtest.FlaggedMessageID, models.ModerationRejected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reviewed.ModerationStatus != models.ModerationRejected || reviewed.ReviewedBy == nil || *reviewed.ReviewedBy != pgtest.RaviID {
+		t.Fatalf("got %+v", reviewed)
+	}
+
+	added, err := h.AddBlocklistTerm(ctx, pgtest.RaviID, "Copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := h.AddBlocklistTerm(ctx, pgtest.RaviID, "copy"); err != nil || again.ID != added.ID {
+		t.Fatalf("adding a term twice: got %+v, %v, want the existing term", again, err)
+	}
+	terms, err := h.FetchBlocklistTermsBySCS(ctx, pgtest.Maths2025SCSID)
+	if err != nil || len(terms) != 1 || terms[0] != "copy" {
+		t.Fatalf("got %v, %v", terms, err)
+	}
+}
+
+func TestAdminConstraints(t *testing.T) {
+	h := &handlers.AdminHandler{DB: pgtest.Require(t, pgtest.FixtureSchool)}
+	ctx := context.Background()
+
+	if _, err := h.CreateNamed(ctx, handlers.SchoolsTable, "Demo School"); !handlers.IsUniqueViolation(err) {
+		t.Fatalf("duplicate school: got %v, want a unique violation", err)
+	}
+	if err := h.DeleteNamed(ctx, handlers.ClassesTable, pgtest.Class8ID); !handlers.IsForeignKeyViolation(err) {
+		t.Fatalf("class in use: got %v, want a foreign key violation", err)
+	}
+
+	duplicate := models.SCSMappingRequest{SchoolID: pgtest.DemoSchoolID, ClassID: pgtest.Class9ID, SubjectID: pgtest.MathematicsID, Year: 2025}
+	if _, err := h.CreateSCSMapping(ctx, duplicate); !handlers.IsUniqueViolation(err) {
+		t.Fatalf("duplicate mapping: got %v, want a unique violation", err)
+	}
+	mappings, err := h.FetchSCSMappings(ctx, pgtest.DemoSchoolID, 2025)
+	if err != nil || len(mappings) != 2 {
+		t.Fatalf("got %+v, %v", mappings, err)
+	}
+
+	unenrolled, err := h.UnenrollStudent(ctx, pgtest.Maths2025SCSID, pgtest.AshaID)
+	if err != nil || unenrolled.IsActive {
+		t.Fatalf("unenroll: got %+v, %v", unenrolled, err)
+	}
+	reenrolled, err := h.EnrollStudent(ctx, pgtest.Maths2025SCSID, pgtest.AshaID)
+	if err != nil || !reenrolled.IsActive || reenrolled.ID != unenrolled.ID {
+		t.Fatalf("re-enroll: got %+v, %v, want the same row reactivated", reenrolled, err)
+	}
+	if _, err := h.EnrollStudent(ctx, pgtest.Maths2025SCSID, pgtest.RaviID); !handlers.IsForeignKeyViolation(err) {
+		t.Fatalf("enrolling a teacher: got %v, want a foreign key violation", err)
+	}
+}
+
+func TestFetchTeacherByTeacherID(t *testing.T) {
+	h := &handlers.TeacherHandler{DB: pgtest.Require(t, pgtest.FixtureSchool)}
+	ctx := context.Background()
+
+	teacher, err := h.FetchTeacherByTeacherID(ctx, "T-1")
+	if err != nil || teacher.ID != pgtest.RaviID || teacher.School != pgtest.DemoSchoolID || teacher.DOB != "" || teacher.Password != "" {
+		t.Fatalf("got %+v, %v", teacher, err)
+	}
+	if _, err := h.FetchTeacherByTeacherID(ctx, "T-404"); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("unknown teacher: got %v, want pgx.ErrNoRows", err)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	h := &handlers.UserHandler{DB: pgtest.Require(t, pgtest.FixtureSchool)}
+	ctx := context.Background()
+
+	for _, tc := range []struct{ role, email, id string }{
+		{config.RoleStudent, "asha@demo.local", pgtest.AshaID},
+		{config.RoleTeacher, "ravi@demo.local", pgtest.RaviID},
+		{config.RoleAdmin, "meera@demo.local", pgtest.MeeraAdminID},
+	} {
+		account, err := h.Authenticate(ctx, tc.role, tc.email, pgtest.FixturePassword)
+		if err != nil || account.ID != tc.id || account.Role != tc.role {
+			t.Fatalf("%s: got %+v, %v", tc.role, account, err)
+		}
+	}
+
+	if _, err := h.Authenticate(ctx, config.RoleStudent, "asha@demo.local", "wrong"); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("wrong password: got %v, want pgx.ErrNoRows", err)
+	}
+	if err := h.SetPassword(ctx, config.RoleStudent, pgtest.AshaID, "changed-456"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Authenticate(ctx, config.RoleStudent, "asha@demo.local", "changed-456"); err != nil {
+		t.Fatalf("new password: %v", err)
+	}
+	if err := h.SetPassword(ctx, config.RoleStudent, pgtest.RaviID, "changed-456"); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("unknown student: got %v, want pgx.ErrNoRows", err)
+	}
+
+	if _, err := h.DisableUser(ctx, config.RoleTeacher, "ravi@demo.local"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Authenticate(ctx, config.RoleTeacher, "ravi@demo.local", pgtest.FixturePassword); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("disabled teacher: got %v, want pgx.ErrNoRows", err)
+	}
+	if account, err := h.FetchUserByID(ctx, config.RoleTeacher, pgtest.RaviID); err != nil || account.Email != "ravi@demo.local" || account.DisabledAt == nil {
+		t.Fatalf("disabled teacher by id: got %+v, %v", account, err)
+	}
+	if _, err := h.FetchUserByID(ctx, config.RoleTeacher, pgtest.AshaID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("student id as teacher: got %v, want pgx.ErrNoRows", err)
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"backend/models"
+	"context"
+	"strings"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ModerationHandler struct {
+	DB *pgxpool.Pool
+}
+
+// teacherScope restricts chats (c) joined with their SCS (scs) to those a teacher ($1) may review:
+// chats assigned to the teacher and chats of subjects taught at the teacher's school.
+const teacherScope = `(c.teacher_id = $1 OR scs.school_id::text = (SELECT school FROM teachers WHERE id = $1))`
+
+// FetchBlocklistTermsBySCS returns the blocklisted terms of the school owning the SCS.
+func (c *ModerationHandler) FetchBlocklistTermsBySCS(ctx context.Context, scsID string) ([]string, error) {
+	var terms []string
+	query := `
+		SELECT b.term
+		FROM school_blocklist_terms AS b
+		JOIN school_class_subject_mapping AS scs ON scs.school_id = b.school_id
+		WHERE scs.id = $1
+	`
+	err := pgxscan.Select(ctx, c.DB, &terms, query, scsID)
+	if err != nil {
+		return nil, err
+	}
+	return terms, nil
+}
+
+func (c *ModerationHandler) FetchFlaggedMessages(ctx context.Context, teacherID string) ([]models.FlaggedMessage, error) {
+	var messages []models.FlaggedMessage
+	query := `
+		SELECT m.*, c.student_id, c.scs_id
+		FROM public_messages AS m
+		JOIN public_chats AS c ON c.id = m.chat_id
+		LEFT JOIN school_class_subject_mapping AS scs ON scs.id = c.scs_id
+		WHERE m.moderation_status = 'flagged' AND ` + teacherScope + `
+		ORDER BY m.created_at
+	`
+	err := pgxscan.Select(ctx, c.DB, &messages, query, teacherID)
+	if err != nil {
+		return []models.FlaggedMessage{}, err
+	}
+	return messages, nil
+}
+
+// ReviewMessage records a teacher decision on a flagged message. It returns pgx.ErrNoRows
+// when the message is not flagged or outside the teacher's scope.
+func (c *ModerationHandler) ReviewMessage(ctx context.Context, teacherID string, messageID string, status string) (models.FlaggedMessage, error) {
+	var message models.FlaggedMessage
+	query := `
+		UPDATE public_messages AS m
+		SET moderation_status = $3, reviewed_by = $1, reviewed_at = now(), updated_at = now()
+		FROM public_chats AS c
+		LEFT JOIN school_class_subject_mapping AS scs ON scs.id = c.scs_id
+		WHERE m.chat_id = c.id AND m.id = $2 AND m.moderation_status = 'flagged' AND ` + teacherScope + `
+		RETURNING m.*, c.student_id, c.scs_id
+	`
+	err := pgxscan.Get(ctx, c.DB, &message, query, teacherID, messageID, status)
+	if err != nil {
+		return models.FlaggedMessage{}, err
+	}
+	return message, nil
+}
+
+func (c *ModerationHandler) FetchBlocklist(ctx context.Context, teacherID string) ([]models.BlocklistTerm, error) {
+	var terms []models.BlocklistTerm
+	query := `
+		SELECT b.*
+		FROM school_blocklist_terms AS b
+		JOIN teachers AS t ON t.school = b.school_id::text
+		WHERE t.id = $1
+		ORDER BY b.term
+	`
+	err := pgxscan.Select(ctx, c.DB, &terms, query, teacherID)
+	if err != nil {
+		return []models.BlocklistTerm{}, err
+	}
+	return terms, nil
+}
+
+func (c *ModerationHandler) AddBlocklistTerm(ctx context.Context, teacherID string, term string) (models.BlocklistTerm, error) {
+	var blocklistTerm models.BlocklistTerm
+	query := `
+		INSERT INTO school_blocklist_terms (school_id, term, created_by)
+		SELECT s.id, $2, t.id
+		FROM teachers AS t
+		JOIN schools AS s ON s.id::text = t.school
+		WHERE t.id = $1
+		ON CONFLICT (school_id, term) DO UPDATE SET term = EXCLUDED.term
+		RETURNING *
+	`
+	err := pgxscan.Get(ctx, c.DB, &blocklistTerm, query, teacherID, strings.ToLower(term))
+	if err != nil {
+		return models.BlocklistTerm{}, err
+	}
+	return blocklistTerm, nil
+}
+
+func (c *ModerationHandler) DeleteBlocklistTerm(ctx context.Context, teacherID string, id string) error {
+	commandTag, err := c.DB.Exec(
+		ctx,
+		`DELETE FROM school_blocklist_terms AS b
+		USING teachers AS t
+		WHERE b.id = $2 AND t.id = $1 AND t.school = b.school_id::text`,
+		teacherID,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"backend/models"
+	"context"
+	"strconv"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+// FetchSimilarQuestions uses pg_trgm similarity to find answered questions asked in the same SCS.
+// The % operator lets the trigram index narrow the candidates; it compares against
+// pg_trgm.similarity_threshold, which is set to minScore for this transaction only.
+func (c *StudentHandler) FetchSimilarQuestions(ctx context.Context, scsID string, question string, minScore float64, limit int) ([]models.SimilarQuestion, error) {
+	var matches []models.SimilarQuestion
+	query := `
+		SELECT
+			m.id AS message_id,
+			m.chat_id,
+			m.question,
+			m.answer,
+			similarity(m.question, $2) AS score,
+			'trigram' AS source
+		FROM public_messages AS m
+		JOIN public_chats AS c ON c.id = m.chat_id
+		WHERE c.scs_id = $1
+			AND m.answer IS NOT NULL
+			AND m.moderation_status = 'approved'
+			AND m.question % $2
+		ORDER BY score DESC
+		LIMIT $3
+	`
+	err := pgx.BeginTxFunc(ctx, c.reader(ctx), pgx.TxOptions{AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, strconv.FormatFloat(minScore, 'f', -1, 64))
+		if err != nil {
+			return err
+		}
+		return pgxscan.Select(ctx, tx, &matches, query, scsID, question, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// FetchAnsweredQuestions returns every answered question with its SCS, used to warm the embedding index.
+func (c *StudentHandler) FetchAnsweredQuestions(ctx context.Context) ([]models.AnsweredQuestion, error) {
+	var questions []models.AnsweredQuestion
+	query := `
+		SELECT m.id AS message_id, m.chat_id, c.scs_id, m.question, m.answer
+		FROM public_messages AS m
+		JOIN public_chats AS c ON c.id = m.chat_id
+		WHERE c.scs_id IS NOT NULL AND m.answer IS NOT NULL AND m.moderation_status = 'approved'
+	`
+	err := pgxscan.Select(ctx, c.DB, &questions, query)
+	if err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
+// FetchAnsweredMessageInSCS returns an answered message only if it belongs to a chat of the given SCS.
+func (c *StudentHandler) FetchAnsweredMessageInSCS(ctx context.Context, scsID string, messageID string) (models.PublicChatMessage, error) {
+	var message models.PublicChatMessage
+	query := `
+		SELECT m.*
+		FROM public_messages AS m
+		JOIN public_chats AS c ON c.id = m.chat_id
+		WHERE m.id = $1 AND c.scs_id = $2 AND m.answer IS NOT NULL
+	`
+	err := pgxscan.Get(ctx, c.DB, &message, query, messageID, scsID)
+	if err != nil {
+		return models.PublicChatMessage{}, err
+	}
+	return message, nil
+}
+
+// CreateChatMessage stores a question with its moderation verdict. A non-nil answer marks it answered immediately.
+func (c *StudentHandler) CreateChatMessage(ctx context.Context, chatID string, question string, answer *string, verdict models.ModerationVerdict) (models.PublicChatMessage, error) {
+	var message models.PublicChatMessage
+	query := `
+		INSERT INTO public_messages (chat_id, question, answer, answered_at, moderation_status, moderation_reasons)
+		VALUES ($1, $2, $3::text, CASE WHEN $3::text IS NULL THEN NULL ELSE now() END, $4, $5)
+		RETURNING *
+	`
+	err := pgxscan.Get(ctx, c.DB, &message, query, chatID, question, answer, verdict.Status, verdict.Reasons)
+	if err != nil {
+		return models.PublicChatMessage{}, err
+	}
+	return message, nil
+}
+
+func (c *StudentHandler) SaveMessageAnswer(ctx context.Context, messageID string, answer string) error {
+	_, err := c.DB.Exec(
+		ctx,
+		"UPDATE public_messages SET answer=$1, answered_at=now(), updated_at=now() WHERE id=$2",
+		answer,
+		messageID,
+	)
+	return err
+}
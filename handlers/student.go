@@ -10,6 +10,22 @@ import (
 
 type StudentHandler struct {
 	DB *pgxpool.Pool
+	// Replicas serves the chat history, SCS and similar question reads when set;
+	// replica.Router implements it.
+	Replicas ReadRouter
+}
+
+// ReadRouter picks the pool for a read-only query that tolerates replication lag.
+type ReadRouter interface {
+	Reader(ctx context.Context) *pgxpool.Pool
+}
+
+// reader is the pool for reads that may be served by a replica.
+func (c *StudentHandler) reader(ctx context.Context) *pgxpool.Pool {
+	if c.Replicas == nil {
+		return c.DB
+	}
+	return c.Replicas.Reader(ctx)
 }
 
 // ------------------
@@ -27,48 +43,52 @@ type VerifyStudentOTPRequest struct {
 // ------------------
 // DB Helper
 // ------------------
-func (c *StudentHandler) FetchStudentByID(id string) (models.Student, error) {
+func (c *StudentHandler) FetchStudentByID(ctx context.Context, id string) (models.Student, error) {
 	var student models.Student
 	query := `SELECT id, full_name, email, phone_number, image
               FROM students WHERE id=$1`
-	err := pgxscan.Get(context.Background(), c.DB, &student, query, id)
+	err := pgxscan.Get(ctx, c.DB, &student, query, id)
 	if err != nil {
 		return models.Student{}, err
 	}
 	return student, nil
 }
 
-func (c *StudentHandler) FetchChatList(id string) ([]models.PublicChat, error) {
+// FetchChatList returns the student's chats, newest first. LIMIT NULL is LIMIT ALL, so a
+// zero page limit returns every chat.
+func (c *StudentHandler) FetchChatList(ctx context.Context, id string, page models.Page) ([]models.PublicChat, error) {
 	var publicChats []models.PublicChat
-	query := `SELECT * FROM public_chats WHERE student_id=$1`
-	err := pgxscan.Select(context.Background(), c.DB, &publicChats, query, id)
+	query := `SELECT * FROM public_chats WHERE student_id=$1
+              ORDER BY created_at DESC, id LIMIT NULLIF($2, 0) OFFSET $3`
+	err := pgxscan.Select(ctx, c.reader(ctx), &publicChats, query, id, page.Limit, page.Offset)
 	if err != nil {
 		return []models.PublicChat{}, err
 	}
 	return publicChats, nil
 }
 
-func (c *StudentHandler) FetchChatDetailsByID(userID string, chatId string) (models.PublicChat, error) {
+func (c *StudentHandler) FetchChatDetailsByID(ctx context.Context, userID string, chatId string) (models.PublicChat, error) {
 	var publicChat models.PublicChat
 	query := `SELECT * FROM public_chats WHERE id=$1 AND student_id=$2`
-	err := pgxscan.Get(context.Background(), c.DB, &publicChat, query, chatId, userID)
+	err := pgxscan.Get(ctx, c.reader(ctx), &publicChat, query, chatId, userID)
 	if err != nil {
 		return models.PublicChat{}, err
 	}
 	return publicChat, nil
 }
 
-func (c *StudentHandler) FetchChatMessages(chatID string) ([]models.PublicChatMessage, error) {
+func (c *StudentHandler) FetchChatMessages(ctx context.Context, chatID string, page models.Page) ([]models.PublicChatMessage, error) {
 	var publicMessages []models.PublicChatMessage
-	query := `SELECT * FROM public_messages WHERE chat_id=$1 ORDER BY created_at DESC`
-	err := pgxscan.Select(context.Background(), c.DB, &publicMessages, query, chatID)
+	query := `SELECT * FROM public_messages WHERE chat_id=$1
+              ORDER BY created_at DESC, id LIMIT NULLIF($2, 0) OFFSET $3`
+	err := pgxscan.Select(ctx, c.reader(ctx), &publicMessages, query, chatID, page.Limit, page.Offset)
 	if err != nil {
 		return []models.PublicChatMessage{}, err
 	}
 	return publicMessages, nil
 }
 
-func (c *StudentHandler) FetchSCSDetailsByUserID(userID string) ([]models.YearWiseDetails, error) {
+func (c *StudentHandler) FetchSCSDetailsByUserID(ctx context.Context, userID string) ([]models.YearWiseDetails, error) {
 	query := `
 		SELECT 
 			scs.year,
@@ -93,7 +113,7 @@ func (c *StudentHandler) FetchSCSDetailsByUserID(userID string) ([]models.YearWi
 		ORDER BY scs.year;
 	`
 
-	rows, err := c.DB.Query(context.Background(), query, userID)
+	rows, err := c.reader(ctx).Query(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -1,17 +1,28 @@
 package handlers
 
 import (
+	"backend/apperror"
+	"backend/metrics"
 	"backend/models"
+	"backend/tracing"
+	"context"
 	"crypto/rand"
-	"database/sql"
 	"encoding/hex"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+type TeacherHandler struct {
+	DB *pgxpool.Pool
+}
+
 type TeacherLoginRequest struct {
 	TeacherID string `json:"teacher_id"`
 }
@@ -21,33 +32,44 @@ type VerifyTeacherOTPRequest struct {
 	OTP string `json:"otp"`
 }
 
+// FetchTeacherByTeacherID looks a teacher up by the school-issued teacher_id, without the password.
+func (c *TeacherHandler) FetchTeacherByTeacherID(ctx context.Context, teacherID string) (models.Teacher, error) {
+	var teacher models.Teacher
+	query := `SELECT id, teacher_id, full_name, email, phone, school, COALESCE(dob::text, '') AS dob, image
+              FROM teachers WHERE teacher_id=$1`
+	err := pgxscan.Get(ctx, c.DB, &teacher, query, teacherID)
+	return teacher, err
+}
+
 // TeacherLoginHandler generates OTP
-func TeacherLoginHandler(db *sql.DB, mc *memcache.Client, otpTTLSeconds int) gin.HandlerFunc {
+func TeacherLoginHandler(teachers *TeacherHandler, mc *memcache.Client, otpTTLSeconds int) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req TeacherLoginRequest
 		if err := c.ShouldBindJSON(&req); err != nil || req.TeacherID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "teacher_id required"})
+			apperror.Respond(c, apperror.Validation("teacher_id required"))
 			return
 		}
 
-		var t models.Teacher
-		err := db.QueryRow(`
-			SELECT id, teacher_id, full_name, email, phone, school, dob, image
-			FROM teachers WHERE teacher_id=$1
-		`, req.TeacherID).Scan(&t.ID, &t.TeacherID, &t.FullName, &t.Email, &t.Phone, &t.School, &t.DOB, &t.Image)
+		t, err := teachers.FetchTeacherByTeacherID(c.Request.Context(), req.TeacherID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			apperror.Respond(c, apperror.NotFound("teacher not found"))
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "teacher not found"})
+			apperror.Respond(c, apperror.Internal(err, "failed to fetch teacher"))
 			return
 		}
 
 		uid := generateUID()
 		otp := generateOTP()
 
+		_, span := tracing.StartMemcacheSpan(c.Request.Context(), "set")
 		mc.Set(&memcache.Item{
 			Key:        "teacher_otp_" + uid,
 			Value:      []byte(otp),
 			Expiration: int32(otpTTLSeconds),
 		})
+		span.End()
 
 		c.JSON(http.StatusOK, gin.H{
 			"uid":     uid,
@@ -63,22 +85,27 @@ func VerifyTeacherOTPHandler(mc *memcache.Client) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req VerifyTeacherOTPRequest
 		if err := c.ShouldBindJSON(&req); err != nil || req.UID == "" || req.OTP == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "uid & otp required"})
+			apperror.Respond(c, apperror.Validation("uid & otp required"))
 			return
 		}
 
+		_, span := tracing.StartMemcacheSpan(c.Request.Context(), "get")
 		item, err := mc.Get("teacher_otp_" + req.UID)
+		span.End()
+		metrics.ObserveMemcacheGet("teacher_otp", err)
 		if err != nil || string(item.Value) != req.OTP {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid otp"})
+			apperror.Respond(c, apperror.Unauthorized("invalid otp"))
 			return
 		}
 
 		sessionToken := generateSessionToken()
+		_, span = tracing.StartMemcacheSpan(c.Request.Context(), "set")
 		mc.Set(&memcache.Item{
 			Key:        "teacher_session_" + req.UID,
 			Value:      []byte(sessionToken),
 			Expiration: int32(time.Hour.Seconds()),
 		})
+		span.End()
 
 		c.JSON(http.StatusOK, gin.H{"session_token": sessionToken})
 	}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"backend/config"
+	"backend/models"
+	"context"
+	"fmt"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// UserHandler manages accounts of every role; the role picks the table.
+type UserHandler struct {
+	DB *pgxpool.Pool
+}
+
+type userTable struct {
+	table      string
+	externalID string // SQL expression for the student_id/teacher_id column
+}
+
+func tableForRole(role string) (userTable, error) {
+	switch role {
+	case config.RoleStudent:
+		return userTable{table: "students", externalID: "student_id"}, nil
+	case config.RoleTeacher:
+		return userTable{table: "teachers", externalID: "teacher_id"}, nil
+	case config.RoleAdmin:
+		return userTable{table: "admins", externalID: "NULL::text"}, nil
+	}
+	return userTable{}, fmt.Errorf("unknown role %q, expected student, teacher or admin", role)
+}
+
+func (t userTable) returning(role string) string {
+	return fmt.Sprintf(`RETURNING id, '%s' AS role, %s AS external_id, full_name, email, disabled_at`, role, t.externalID)
+}
+
+func (c *UserHandler) FetchUserByEmail(ctx context.Context, role string, email string) (models.UserAccount, error) {
+	var account models.UserAccount
+	t, err := tableForRole(role)
+	if err != nil {
+		return account, err
+	}
+	query := fmt.Sprintf(
+		`SELECT id, '%s' AS role, %s AS external_id, full_name, email, disabled_at FROM %s WHERE email=$1`,
+		role, t.externalID, t.table,
+	)
+	err = pgxscan.Get(ctx, c.DB, &account, query, email)
+	return account, err
+}
+
+// FetchUserByID returns the account with the given id, disabled or not.
+func (c *UserHandler) FetchUserByID(ctx context.Context, role string, id string) (models.UserAccount, error) {
+	var account models.UserAccount
+	t, err := tableForRole(role)
+	if err != nil {
+		return account, err
+	}
+	query := fmt.Sprintf(
+		`SELECT id, '%s' AS role, %s AS external_id, full_name, email, disabled_at FROM %s WHERE id=$1`,
+		role, t.externalID, t.table,
+	)
+	err = pgxscan.Get(ctx, c.DB, &account, query, id)
+	return account, err
+}
+
+func (c *UserHandler) CreateUser(ctx context.Context, req models.CreateUserRequest) (models.UserAccount, error) {
+	var account models.UserAccount
+	t, err := tableForRole(req.Role)
+	if err != nil {
+		return account, err
+	}
+
+	var query string
+	var args []any
+	switch req.Role {
+	case config.RoleStudent:
+		query = `INSERT INTO students (student_id, full_name, email, phone_number, password) VALUES ($1, $2, $3, $4, $5) `
+		args = []any{req.ExternalID, req.FullName, req.Email, req.Phone, req.Password}
+	case config.RoleTeacher:
+		query = `INSERT INTO teachers (teacher_id, full_name, email, phone, school, password) VALUES ($1, $2, $3, $4, $5, $6) `
+		args = []any{req.ExternalID, req.FullName, req.Email, req.Phone, req.School, req.Password}
+	case config.RoleAdmin:
+		query = `INSERT INTO admins (full_name, email, password) VALUES ($1, $2, $3) `
+		args = []any{req.FullName, req.Email, req.Password}
+	}
+	err = pgxscan.Get(ctx, c.DB, &account, query+t.returning(req.Role), args...)
+	return account, err
+}
+
+// DisableUser blocks future logins; tokens already issued stay valid until they expire.
+func (c *UserHandler) DisableUser(ctx context.Context, role string, email string) (models.UserAccount, error) {
+	var account models.UserAccount
+	t, err := tableForRole(role)
+	if err != nil {
+		return account, err
+	}
+	query := fmt.Sprintf(`UPDATE %s SET disabled_at = COALESCE(disabled_at, now()) WHERE email=$1 `, t.table) + t.returning(role)
+	err = pgxscan.Get(ctx, c.DB, &account, query, email)
+	return account, err
+}
+
+func (c *UserHandler) ResetPassword(ctx context.Context, role string, email string, password string) (models.UserAccount, error) {
+	var account models.UserAccount
+	t, err := tableForRole(role)
+	if err != nil {
+		return account, err
+	}
+	query := fmt.Sprintf(`UPDATE %s SET password=$2 WHERE email=$1 `, t.table) + t.returning(role)
+	err = pgxscan.Get(ctx, c.DB, &account, query, email, password)
+	return account, err
+}
+
+// Authenticate returns the enabled account with the given credentials, or pgx.ErrNoRows.
+func (c *UserHandler) Authenticate(ctx context.Context, role string, email string, password string) (models.UserAccount, error) {
+	var account models.UserAccount
+	t, err := tableForRole(role)
+	if err != nil {
+		return account, err
+	}
+	query := fmt.Sprintf(
+		`SELECT id, '%s' AS role, %s AS external_id, full_name, email, disabled_at FROM %s
+		WHERE email=$1 AND password=$2 AND disabled_at IS NULL`,
+		role, t.externalID, t.table,
+	)
+	err = pgxscan.Get(ctx, c.DB, &account, query, email, password)
+	return account, err
+}
+
+// SetPassword changes the password of the account with the given id. It returns
+// pgx.ErrNoRows when there is no such account.
+func (c *UserHandler) SetPassword(ctx context.Context, role string, id string, password string) error {
+	t, err := tableForRole(role)
+	if err != nil {
+		return err
+	}
+	commandTag, err := c.DB.Exec(ctx, fmt.Sprintf(`UPDATE %s SET password=$2 WHERE id=$1`, t.table), id, password)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check probes one dependency. Run must respect ctx so a hung dependency cannot block readiness.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+type Result struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// RunChecks executes checks concurrently, each bounded by timeout, and reports whether all passed.
+func RunChecks(ctx context.Context, timeout time.Duration, checks []Check) (bool, map[string]Result) {
+	results := make(map[string]Result, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			started := time.Now()
+			errCh := make(chan error, 1)
+			go func() { errCh <- check.Run(checkCtx) }()
+
+			var err error
+			select {
+			case err = <-errCh:
+			case <-checkCtx.Done():
+				err = checkCtx.Err()
+			}
+
+			result := Result{Status: StatusOK, LatencyMS: time.Since(started).Milliseconds()}
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	healthy := true
+	for _, result := range results {
+		if result.Status != StatusOK {
+			healthy = false
+		}
+	}
+	return healthy, results
+}
@@ -0,0 +1,17 @@
+package lifecycle
+
+import "sync/atomic"
+
+// Readiness tells load balancers whether this replica should receive traffic.
+// It starts not ready, flips to ready once serving and back before draining.
+type Readiness struct {
+	ready atomic.Bool
+}
+
+func (r *Readiness) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+func (r *Readiness) Ready() bool {
+	return r.ready.Load()
+}
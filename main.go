@@ -2,15 +2,91 @@ package main
 
 import (
 	"backend/config"
-	"backend/routes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
 )
 
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+const usage = `usage: buddhit-tech <command> [flags]
+
+commands:
+  serve                                 start the HTTP server (default)
+  migrate up|down|status                manage the database schema
+  seed                                  insert demo school, classes, subjects and users
+  user create|disable|reset-password    manage student, teacher and admin accounts
+  token issue                           issue a JWT for an existing user
+  import roster                         import students and teachers from CSV/XLSX
+  config                                print the effective configuration, secrets redacted
+`
+
 func main() {
 	config.InitLogger()
-	config.LoadEnv()
-	// Connect to database
-	pool := config.Connect()
-	defer pool.Close()
-	routes.InitServer(pool)
-	select {}
+	env := config.LoadEnv()
+	if err := config.ConfigureLogger(env.LogLevel, env.LogFormat); err != nil {
+		os.Exit(printError(err))
+	}
+	// config and help keep working with an invalid configuration, to help fix it
+	if err := env.Validate(); err != nil && !inspecting(os.Args[1:]) {
+		os.Exit(printError(fmt.Errorf("invalid configuration: %w", err)))
+	}
+	os.Exit(run(os.Args[1:]))
+}
+
+// run dispatches a command and returns the process exit code. Commands print their
+// result as JSON on stdout and failures as {"error": ...} on stderr.
+func run(args []string) int {
+	if len(args) == 0 {
+		return serve(nil)
+	}
+
+	command, rest := args[0], args[1:]
+	switch command {
+	case "serve":
+		return serve(rest)
+	case "migrate":
+		return migrate(rest)
+	case "seed":
+		return seed(rest)
+	case "user":
+		return user(rest)
+	case "token":
+		return token(rest)
+	case "import":
+		return importCommand(rest)
+	case "config":
+		printJSON(config.GetEnv().Redacted())
+		return exitOK
+	case "help", "-h", "--help":
+		fmt.Fprint(os.Stdout, usage)
+		return exitOK
+	}
+	return usageError("unknown command %q", command)
+}
+
+func inspecting(args []string) bool {
+	return len(args) > 0 && slices.Contains([]string{"config", "help", "-h", "--help"}, args[0])
+}
+
+func printJSON(v any) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+func printError(err error) int {
+	json.NewEncoder(os.Stderr).Encode(map[string]string{"error": err.Error()})
+	return exitFailure
+}
+
+func usageError(format string, args ...any) int {
+	json.NewEncoder(os.Stderr).Encode(map[string]string{"error": fmt.Sprintf(format, args...)})
+	fmt.Fprint(os.Stderr, usage)
+	return exitUsage
 }
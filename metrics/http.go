@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware records request count and latency labeled by the route template
+// (e.g. /v1/students/chats/:id), never the raw path, to keep cardinality bounded.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		HTTPRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		HTTPDuration.WithLabelValues(method, route).Observe(time.Since(started).Seconds())
+	}
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "buddhit"
+
+// Registry holds every metric exported at /metrics.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	Logins = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts by role and result (success or failure).",
+	}, []string{"role", "result"})
+
+	AnswerDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "answer_duration_seconds",
+		Help:      "Time the answering engine took per question, by result.",
+		Buckets:   []float64{0.5, 1, 2, 5, 10, 20, 30, 60, 120},
+	}, []string{"result"})
+
+	MemcacheRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "memcache_requests_total",
+		Help:      "Memcache lookups by cache name and result (hit, miss or error).",
+	}, []string{"cache", "result"})
+
+	ReplicaLag = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "db_replica_lag_seconds",
+		Help:      "Replication lag of each read replica at its last check; -1 when unreachable.",
+	}, []string{"replica"})
+
+	DBReads = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_reads_total",
+		Help:      "Read-only queries by the database that served them (primary or replica).",
+	}, []string{"target"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveLogin counts a login attempt.
+func ObserveLogin(role string, success bool) {
+	result := "failure"
+	if success {
+		result = "success"
+	}
+	Logins.WithLabelValues(role, result).Inc()
+}
+
+// ObserveMemcacheGet counts the outcome of a memcache Get so hit ratios can be derived.
+func ObserveMemcacheGet(cache string, err error) {
+	switch {
+	case err == nil:
+		MemcacheRequests.WithLabelValues(cache, "hit").Inc()
+	case errors.Is(err, memcache.ErrCacheMiss):
+		MemcacheRequests.WithLabelValues(cache, "miss").Inc()
+	default:
+		MemcacheRequests.WithLabelValues(cache, "error").Inc()
+	}
+}
+
+// RegisterAnswerQueue exports the current answer queue depth.
+func RegisterAnswerQueue(depth func() int) {
+	factory.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "answer_queue_depth",
+		Help:      "Questions waiting for the answering engine.",
+	}, func() float64 { return float64(depth()) })
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector reads pgxpool statistics at scrape time.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns   *prometheus.Desc
+	idleConns       *prometheus.Desc
+	totalConns      *prometheus.Desc
+	maxConns        *prometheus.Desc
+	acquireCount    *prometheus.Desc
+	acquireDuration *prometheus.Desc
+	emptyAcquire    *prometheus.Desc
+	canceledAcquire *prometheus.Desc
+}
+
+// RegisterPool exports connection pool statistics for pool.
+func RegisterPool(pool *pgxpool.Pool) {
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "pgxpool", name), help, nil, nil)
+	}
+	Registry.MustRegister(&poolCollector{
+		pool:            pool,
+		acquiredConns:   desc("acquired_conns", "Connections currently checked out of the pool."),
+		idleConns:       desc("idle_conns", "Idle connections in the pool."),
+		totalConns:      desc("total_conns", "Total connections in the pool."),
+		maxConns:        desc("max_conns", "Maximum pool size."),
+		acquireCount:    desc("acquire_total", "Successful connection acquisitions."),
+		acquireDuration: desc("acquire_wait_seconds_total", "Total time spent waiting to acquire a connection."),
+		emptyAcquire:    desc("empty_acquire_total", "Acquisitions that had to wait because the pool was empty."),
+		canceledAcquire: desc("canceled_acquire_total", "Acquisitions cancelled by their context."),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquire
+	ch <- c.canceledAcquire
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquire, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquire, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
@@ -1,9 +1,10 @@
 package middleware
 
 import (
+	"backend/apperror"
 	"backend/config"
-	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -13,8 +14,7 @@ func AuthMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authHeader := ctx.GetHeader("Authorization")
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid token"})
-			ctx.Abort()
+			apperror.Respond(ctx, apperror.Unauthorized("missing or invalid token"))
 			return
 		}
 
@@ -29,8 +29,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		})
 
 		if err != nil || !token.Valid {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			ctx.Abort()
+			apperror.Respond(ctx, apperror.Unauthorized("invalid token"))
 			return
 		}
 
@@ -38,8 +37,29 @@ func AuthMiddleware() gin.HandlerFunc {
 		if claims, ok := token.Claims.(jwt.MapClaims); ok {
 			ctx.Set("user_id", claims["user_id"])
 			ctx.Set("email", claims["email"])
+			role, _ := claims["role"].(string)
+			if role == "" && time.Now().Before(env.LegacyStudentTokensUntil) {
+				// the student apps hold nearly all of them; an old teacher token matches no student rows
+				role = config.RoleStudent
+			}
+			ctx.Set("role", role)
 		}
 
 		ctx.Next()
 	}
 }
+
+// RequireRole must run after AuthMiddleware and only lets tokens with one of roles through.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		role := ctx.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				ctx.Next()
+				return
+			}
+		}
+
+		apperror.Respond(ctx, apperror.Forbidden("insufficient permissions"))
+	}
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"backend/apperror"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSPolicy decides which browser origins may call the API.
+type CORSPolicy struct {
+	// AllowedOrigins are exact origins such as https://app.example.com, patterns with one
+	// "*" such as https://*.example.com, or "*" for any origin. Empty allows none.
+	AllowedOrigins []string
+	// AllowCredentials lets browsers send cookies and auth headers. Origins allowed only
+	// through "*" never get credentials, since any site could then act as the user.
+	AllowCredentials bool
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	// ExposedHeaders are the response headers scripts may read, e.g. X-Request-ID.
+	ExposedHeaders []string
+	// MaxAge is how long browsers may cache a preflight answer; zero leaves it to them.
+	MaxAge time.Duration
+}
+
+// match returns the first allowed origin or pattern origin matches.
+func (p CORSPolicy) match(origin string) (string, bool) {
+	origin = strings.ToLower(origin)
+	for _, pattern := range p.AllowedOrigins {
+		if pattern != "*" && matchOrigin(strings.ToLower(pattern), origin) {
+			return pattern, true
+		}
+	}
+	if slices.Contains(p.AllowedOrigins, "*") {
+		return "*", true
+	}
+	return "", false
+}
+
+// matchOrigin matches origin against an exact origin or a pattern whose "*" stands for
+// one or more subdomain labels (or a port, as in http://localhost:*).
+func matchOrigin(pattern string, origin string) bool {
+	if pattern == origin {
+		return true
+	}
+	prefix, suffix, ok := strings.Cut(pattern, "*")
+	if !ok || len(origin) <= len(prefix)+len(suffix) || !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+		return false
+	}
+	wild := origin[len(prefix) : len(origin)-len(suffix)]
+	// the wildcard may not swallow a scheme, port, path or userinfo
+	return !strings.ContainsAny(wild, ":/@") && !strings.HasPrefix(wild, ".") && !strings.HasSuffix(wild, ".")
+}
+
+// CORS answers preflight requests and adds the CORS headers to cross-origin requests.
+// Requests under a path prefix in overrides follow that policy instead of policy; the
+// longest matching prefix wins. It must be installed on the engine rather than a route
+// group, since preflight OPTIONS requests match no registered route.
+func CORS(policy CORSPolicy, overrides map[string]CORSPolicy) gin.HandlerFunc {
+	prefixes := make([]string, 0, len(overrides))
+	for prefix := range overrides {
+		prefixes = append(prefixes, prefix)
+	}
+	// longest first
+	slices.SortFunc(prefixes, func(a, b string) int { return len(b) - len(a) })
+
+	return func(ctx *gin.Context) {
+		p := policy
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(ctx.Request.URL.Path, prefix) {
+				p = overrides[prefix]
+				break
+			}
+		}
+
+		header := ctx.Writer.Header()
+		header.Add("Vary", "Origin")
+		origin := ctx.GetHeader("Origin")
+		if origin == "" {
+			ctx.Next()
+			return
+		}
+		preflight := ctx.Request.Method == http.MethodOptions && ctx.GetHeader("Access-Control-Request-Method") != ""
+
+		pattern, ok := p.match(origin)
+		if !ok {
+			if preflight {
+				apperror.Respond(ctx, apperror.Forbidden("origin not allowed"))
+				return
+			}
+			// the request still runs; the browser hides the answer from the page
+			ctx.Next()
+			return
+		}
+
+		if pattern == "*" {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+			if p.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		if !preflight {
+			if len(p.ExposedHeaders) > 0 {
+				header.Set("Access-Control-Expose-Headers", strings.Join(p.ExposedHeaders, ", "))
+			}
+			ctx.Next()
+			return
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		if !containsFold(p.AllowedMethods, ctx.GetHeader("Access-Control-Request-Method")) {
+			apperror.Respond(ctx, apperror.Forbidden("method not allowed by CORS policy"))
+			return
+		}
+		for _, requested := range strings.Split(ctx.GetHeader("Access-Control-Request-Headers"), ",") {
+			if requested = strings.TrimSpace(requested); requested != "" && !containsFold(p.AllowedHeaders, requested) {
+				apperror.Respond(ctx, apperror.Forbidden("header "+requested+" not allowed by CORS policy"))
+				return
+			}
+		}
+		header.Set("Access-Control-Allow-Methods", strings.Join(p.AllowedMethods, ", "))
+		if len(p.AllowedHeaders) > 0 {
+			header.Set("Access-Control-Allow-Headers", strings.Join(p.AllowedHeaders, ", "))
+		}
+		if p.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge.Seconds())))
+		}
+		ctx.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, value) })
+}
@@ -0,0 +1,136 @@
+package middleware_test
+
+import (
+	"backend/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func corsRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	policy := middleware.CORSPolicy{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.school.example.com", "http://localhost:*"},
+		AllowCredentials: true,
+		AllowedMethods:   []string{http.MethodGet, http.MethodPost},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		MaxAge:           10 * time.Minute,
+	}
+	admin := policy
+	admin.AllowedOrigins = []string{"https://admin.example.com"}
+	public := policy
+	public.AllowedOrigins = []string{"*"}
+
+	router := gin.New()
+	router.Use(middleware.CORS(policy, map[string]middleware.CORSPolicy{"/v1/admin/": admin, "/v1/public/": public}))
+	for _, path := range []string{"/v1/students/chats", "/v1/admin/scs", "/v1/public/students/login"} {
+		router.GET(path, func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	}
+	return router
+}
+
+func preflight(router *gin.Engine, path string, origin string, method string, headers string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORSPreflight(t *testing.T) {
+	router := corsRouter()
+	cases := []struct {
+		name    string
+		path    string
+		origin  string
+		method  string
+		headers string
+		want    int
+	}{
+		{"exact origin", "/v1/students/chats", "https://app.example.com", http.MethodGet, "authorization, content-type", http.StatusNoContent},
+		{"subdomain", "/v1/students/chats", "https://north.school.example.com", http.MethodPost, "", http.StatusNoContent},
+		{"nested subdomain", "/v1/students/chats", "https://a.b.school.example.com", http.MethodGet, "", http.StatusNoContent},
+		{"any port", "/v1/students/chats", "http://localhost:5173", http.MethodGet, "", http.StatusNoContent},
+		{"bare domain of a pattern", "/v1/students/chats", "https://school.example.com", http.MethodGet, "", http.StatusForbidden},
+		{"lookalike domain", "/v1/students/chats", "https://evilschool.example.com", http.MethodGet, "", http.StatusForbidden},
+		{"suffix attack", "/v1/students/chats", "https://app.example.com.evil.com", http.MethodGet, "", http.StatusForbidden},
+		{"other scheme", "/v1/students/chats", "http://app.example.com", http.MethodGet, "", http.StatusForbidden},
+		{"method not allowed", "/v1/students/chats", "https://app.example.com", http.MethodDelete, "", http.StatusForbidden},
+		{"header not allowed", "/v1/students/chats", "https://app.example.com", http.MethodGet, "X-Debug", http.StatusForbidden},
+		{"group override rejects default origin", "/v1/admin/scs", "https://app.example.com", http.MethodGet, "", http.StatusForbidden},
+		{"group override allows its origin", "/v1/admin/scs", "https://admin.example.com", http.MethodGet, "", http.StatusNoContent},
+		{"wildcard group", "/v1/public/students/login", "https://anywhere.test", http.MethodGet, "", http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := preflight(router, tc.path, tc.origin, tc.method, tc.headers)
+			if rec.Code != tc.want {
+				t.Fatalf("got %d, want %d: %v", rec.Code, tc.want, rec.Header())
+			}
+		})
+	}
+}
+
+func TestCORSPreflightHeaders(t *testing.T) {
+	router := corsRouter()
+	rec := preflight(router, "/v1/students/chats", "https://app.example.com", http.MethodPost, "Content-Type")
+	want := map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "GET, POST",
+		"Access-Control-Allow-Headers":     "Authorization, Content-Type",
+		"Access-Control-Max-Age":           "600",
+	}
+	for name, value := range want {
+		if got := rec.Header().Get(name); got != value {
+			t.Errorf("%s: got %q, want %q", name, got, value)
+		}
+	}
+	if vary := rec.Header().Values("Vary"); len(vary) != 3 || vary[0] != "Origin" {
+		t.Errorf("Vary: got %v", vary)
+	}
+
+	// "*" never comes with credentials
+	rec = preflight(router, "/v1/public/students/login", "https://anywhere.test", http.MethodGet, "")
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("wildcard origin: got %q, want *", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("wildcard credentials: got %q, want none", got)
+	}
+}
+
+func TestCORSSimpleRequest(t *testing.T) {
+	router := corsRouter()
+	for origin, want := range map[string]string{
+		"https://app.example.com": "https://app.example.com",
+		"https://evil.test":       "",
+		"":                        "",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/students/chats", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("origin %q: got %d, want 200", origin, rec.Code)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != want {
+			t.Errorf("origin %q: got Access-Control-Allow-Origin %q, want %q", origin, got, want)
+		}
+		exposed := rec.Header().Get("Access-Control-Expose-Headers")
+		if (want != "") != (exposed == "X-Request-ID") {
+			t.Errorf("origin %q: got Access-Control-Expose-Headers %q", origin, exposed)
+		}
+	}
+}
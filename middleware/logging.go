@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"backend/apperror"
+	"backend/config"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// incoming request ids are echoed into logs and headers, so only accept safe values
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID accepts the caller's X-Request-ID or generates one, exposes it as the
+// "request_id" key and response header, and stores a logger carrying it in the request context.
+func RequestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+
+		ctx.Set("request_id", requestID)
+		ctx.Header(RequestIDHeader, requestID)
+
+		fields := []zap.Field{zap.String("request_id", requestID)}
+		if spanContext := trace.SpanContextFromContext(ctx.Request.Context()); spanContext.HasTraceID() {
+			fields = append(fields, zap.String("trace_id", spanContext.TraceID().String()))
+		}
+		requestLogger := config.GetLogger().With(fields...)
+		ctx.Request = ctx.Request.WithContext(config.WithLogger(ctx.Request.Context(), requestLogger))
+
+		ctx.Next()
+	}
+}
+
+// AccessLog writes one structured line per request, including errors attached with ctx.Error.
+func AccessLog() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		started := time.Now()
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		fields := []zap.Field{
+			zap.String("method", ctx.Request.Method),
+			zap.String("route", ctx.FullPath()),
+			zap.String("path", ctx.Request.URL.Path),
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(started)),
+			zap.String("client_ip", ctx.ClientIP()),
+			zap.Int("bytes", ctx.Writer.Size()),
+		}
+		if userID := ctx.GetString("user_id"); userID != "" {
+			fields = append(fields, zap.String("user_id", userID))
+		}
+		if len(ctx.Errors) > 0 {
+			fields = append(fields, zap.Strings("errors", ctx.Errors.Errors()))
+		}
+
+		// a stack trace of the middleware chain adds nothing to an access log line
+		requestLogger := config.LoggerFromContext(ctx.Request.Context()).WithOptions(zap.AddStacktrace(zapcore.DPanicLevel))
+		switch {
+		case status >= http.StatusInternalServerError:
+			requestLogger.Error("request", fields...)
+		case status >= http.StatusBadRequest:
+			requestLogger.Warn("request", fields...)
+		default:
+			requestLogger.Info("request", fields...)
+		}
+	}
+}
+
+// Recovery turns panics into a logged 500 instead of gin's plain text stack dump.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(ctx *gin.Context, recovered any) {
+		config.LoggerFromContext(ctx.Request.Context()).Error("panic_recovered",
+			zap.Any("panic", recovered),
+			zap.Stack("stack"),
+		)
+		apperror.Respond(ctx, apperror.Internal(fmt.Errorf("panic: %v", recovered), "internal server error"))
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
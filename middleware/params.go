@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"backend/apperror"
+	"backend/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UUIDParams answers 400 unless every path parameter is a UUID; all :params under /v1
+// are row ids, so a malformed one never reaches the database.
+func UUIDParams() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var fields []validation.FieldError
+		for _, param := range ctx.Params {
+			fields = append(fields, validation.UUID(param.Key, param.Value)...)
+		}
+		if len(fields) > 0 {
+			apperror.Respond(ctx, apperror.Validation("invalid path parameters").WithDetails(fields))
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"backend/replica"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadYourWrites must run after AuthMiddleware. A user who wrote within the router's
+// window reads from the primary, and every successful request other than GET, HEAD or
+// OPTIONS starts a new window for them.
+func ReadYourWrites(router *replica.Router) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString("user_id")
+		if router.Sticky(userID) {
+			ctx.Request = ctx.Request.WithContext(replica.WithPrimary(ctx.Request.Context()))
+		}
+		ctx.Next()
+
+		switch ctx.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if ctx.Writer.Status() < http.StatusBadRequest {
+				router.Wrote(userID)
+			}
+		}
+	}
+}
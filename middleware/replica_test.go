@@ -0,0 +1,71 @@
+package middleware_test
+
+import (
+	"backend/config"
+	"backend/middleware"
+	"backend/replica"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestReadYourWrites(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.InitLogger()
+	if err := config.ConfigureLogger("fatal", "json"); err != nil {
+		t.Fatal(err)
+	}
+	primary, err := pgxpool.New(context.Background(), "postgres://app@primary/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer primary.Close()
+	replicaPool, err := pgxpool.New(context.Background(), "postgres://app@replica/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replicaPool.Close()
+	router := replica.NewRouter(primary, []*pgxpool.Pool{replicaPool}, time.Second, time.Minute)
+	router.Probe = func(context.Context, *pgxpool.Pool) (time.Duration, error) { return 0, nil }
+	router.CheckLag(context.Background())
+
+	engine := gin.New()
+	engine.Use(func(ctx *gin.Context) { ctx.Set("user_id", ctx.GetHeader("X-User")) }, middleware.ReadYourWrites(router))
+	engine.GET("/chats", func(ctx *gin.Context) {
+		if router.Reader(ctx.Request.Context()) == primary {
+			ctx.String(http.StatusOK, "primary")
+			return
+		}
+		ctx.String(http.StatusOK, "replica")
+	})
+	engine.POST("/chats", func(ctx *gin.Context) { ctx.Status(http.StatusCreated) })
+	engine.POST("/invalid", func(ctx *gin.Context) { ctx.Status(http.StatusBadRequest) })
+
+	do := func(method string, path string, user string) string {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-User", user)
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+
+	if got := do(http.MethodGet, "/chats", "alice"); got != "replica" {
+		t.Fatalf("before any write: got %s", got)
+	}
+	do(http.MethodPost, "/invalid", "alice")
+	if got := do(http.MethodGet, "/chats", "alice"); got != "replica" {
+		t.Fatalf("after a failed write: got %s", got)
+	}
+	do(http.MethodPost, "/chats", "alice")
+	if got := do(http.MethodGet, "/chats", "alice"); got != "primary" {
+		t.Fatalf("after a write: got %s, want primary", got)
+	}
+	if got := do(http.MethodGet, "/chats", "bob"); got != "replica" {
+		t.Fatalf("another user: got %s, want replica", got)
+	}
+}
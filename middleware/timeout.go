@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout gives the request context a deadline so database work is cancelled once it
+// passes, as it is when the client disconnects. A zero timeout leaves the request unbounded.
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if timeout <= 0 {
+			ctx.Next()
+			return
+		}
+		requestCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+		defer cancel()
+		ctx.Request = ctx.Request.WithContext(requestCtx)
+		ctx.Next()
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"backend/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireClientCert answers 403 unless the connection presented a client certificate
+// the server verified against its client CAs (see certs.Reloader).
+func RequireClientCert() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.TLS == nil || len(ctx.Request.TLS.VerifiedChains) == 0 {
+			apperror.Respond(ctx, apperror.Forbidden("client certificate required"))
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"backend/config"
+	"backend/migrations"
+	"context"
+	"flag"
+)
+
+func migrate(args []string) int {
+	if len(args) == 0 {
+		return usageError("migrate needs up, down or status")
+	}
+	direction := args[0]
+
+	flags := flag.NewFlagSet("migrate "+direction, flag.ContinueOnError)
+	steps := flags.Int("steps", 1, "number of migrations to revert with down")
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	if direction != "up" && direction != "down" && direction != "status" {
+		return usageError("unknown migrate command %q, expected up, down or status", direction)
+	}
+
+	pool, err := config.Connect(context.Background())
+	if err != nil {
+		return printError(err)
+	}
+	defer pool.Close()
+
+	migrator, err := migrations.NewMigrator(pool)
+	if err != nil {
+		return printError(err)
+	}
+
+	ctx := context.Background()
+	var result any
+	switch direction {
+	case "up":
+		result, err = migrator.Up(ctx)
+	case "down":
+		result, err = migrator.Down(ctx, *steps)
+	case "status":
+		result, err = migrator.Status(ctx)
+	}
+	if err != nil {
+		return printError(err)
+	}
+	printJSON(result)
+	return exitOK
+}
@@ -0,0 +1,66 @@
+package migrations_test
+
+import (
+	"backend/migrations"
+	"backend/pgtest"
+	"context"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) { os.Exit(pgtest.Main(m)) }
+
+// TestDownAndUp reverts every migration and applies them again, so each down script
+// must undo its up script completely.
+func TestDownAndUp(t *testing.T) {
+	pool := pgtest.Require(t)
+	ctx := context.Background()
+	migrator, err := migrations.NewMigrator(pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reverted, err := migrator.Down(ctx, int(migrator.Latest()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version, err := migrator.Current(ctx); err != nil || version != 0 {
+		t.Fatalf("after down: got version %d, %v", version, err)
+	}
+
+	// status on a database that was never migrated reads without creating the table
+	if _, err := pool.Exec(ctx, `DROP TABLE schema_migrations`); err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) == 0 || statuses[len(statuses)-1].Version != migrator.Latest() {
+		t.Fatalf("status: got %+v, want every migration", statuses)
+	}
+	for _, status := range statuses {
+		if status.Applied {
+			t.Fatalf("status without schema_migrations: %d is applied", status.Version)
+		}
+	}
+	var exists bool
+	if err := pool.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil || exists {
+		t.Fatalf("status created schema_migrations: %v, %v", exists, err)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(reverted) {
+		t.Fatalf("reverted %d migrations but applied %d", len(reverted), len(applied))
+	}
+	if version, err := migrator.Current(ctx); err != nil || version != migrator.Latest() {
+		t.Fatalf("after up: got version %d, %v, want %d", version, err, migrator.Latest())
+	}
+
+	if err := pgtest.Load(ctx, pool, pgtest.FixtureSchool); err != nil {
+		t.Fatalf("fixtures on the re-created schema: %v", err)
+	}
+}
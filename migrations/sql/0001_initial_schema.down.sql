@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS public_messages;
+DROP TABLE IF EXISTS public_chats;
+DROP TABLE IF EXISTS student_scs_mapping;
+DROP TABLE IF EXISTS school_class_subject_mapping;
+DROP TABLE IF EXISTS subjects;
+DROP TABLE IF EXISTS classes;
+DROP TABLE IF EXISTS schools;
+DROP TABLE IF EXISTS teachers;
+DROP TABLE IF EXISTS students;
@@ -0,0 +1,97 @@
+-- Tables that predate versioned migrations. IF NOT EXISTS lets this run against
+-- databases that were created by hand.
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+CREATE TABLE IF NOT EXISTS students (
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    student_id   text NOT NULL UNIQUE,
+    full_name    text NOT NULL,
+    email        text NOT NULL UNIQUE,
+    phone_number text NOT NULL DEFAULT '',
+    dob          date,
+    image        text,
+    password     text,
+    created_at   timestamptz NOT NULL DEFAULT now(),
+    updated_at   timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS teachers (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    teacher_id text NOT NULL UNIQUE,
+    full_name  text NOT NULL,
+    email      text NOT NULL UNIQUE,
+    phone      text NOT NULL DEFAULT '',
+    school     text NOT NULL DEFAULT '',
+    dob        date,
+    image      text NOT NULL DEFAULT '',
+    password   text,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS schools (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       text NOT NULL UNIQUE,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS classes (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       text NOT NULL UNIQUE,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS subjects (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       text NOT NULL UNIQUE,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS school_class_subject_mapping (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id  uuid NOT NULL REFERENCES schools (id),
+    class_id   uuid NOT NULL REFERENCES classes (id),
+    subject_id uuid NOT NULL REFERENCES subjects (id),
+    year       integer NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (school_id, class_id, subject_id, year)
+);
+
+CREATE TABLE IF NOT EXISTS student_scs_mapping (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    student_id uuid NOT NULL REFERENCES students (id),
+    scs_id     uuid NOT NULL REFERENCES school_class_subject_mapping (id),
+    is_active  boolean NOT NULL DEFAULT true,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (student_id, scs_id)
+);
+
+CREATE TABLE IF NOT EXISTS public_chats (
+    id                uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    student_id        uuid REFERENCES students (id),
+    title             text,
+    description       text,
+    teacher_global_id text,
+    teacher_id        uuid REFERENCES teachers (id),
+    created_at        timestamptz DEFAULT now(),
+    updated_at        timestamptz DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS public_chats_student_id_idx ON public_chats (student_id);
+
+CREATE TABLE IF NOT EXISTS public_messages (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    chat_id     uuid NOT NULL REFERENCES public_chats (id) ON DELETE CASCADE,
+    question    text NOT NULL,
+    answer      text,
+    created_at  timestamptz NOT NULL DEFAULT now(),
+    answered_at timestamptz,
+    updated_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS public_messages_chat_id_created_at_idx ON public_messages (chat_id, created_at DESC);
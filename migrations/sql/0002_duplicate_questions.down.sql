@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS public_messages_question_trgm_idx;
+ALTER TABLE public_chats DROP COLUMN IF EXISTS scs_id;
@@ -0,0 +1,6 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE public_chats ADD COLUMN scs_id uuid REFERENCES school_class_subject_mapping (id);
+CREATE INDEX public_chats_scs_id_idx ON public_chats (scs_id);
+
+CREATE INDEX public_messages_question_trgm_idx ON public_messages USING gin (question gin_trgm_ops);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS school_blocklist_terms;
+DROP INDEX IF EXISTS public_messages_flagged_idx;
+ALTER TABLE public_messages
+    DROP COLUMN IF EXISTS reviewed_at,
+    DROP COLUMN IF EXISTS reviewed_by,
+    DROP COLUMN IF EXISTS moderation_reasons,
+    DROP COLUMN IF EXISTS moderation_status;
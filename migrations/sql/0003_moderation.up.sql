@@ -0,0 +1,17 @@
+ALTER TABLE public_messages
+    ADD COLUMN moderation_status  text NOT NULL DEFAULT 'approved'
+        CHECK (moderation_status IN ('approved', 'flagged', 'rejected')),
+    ADD COLUMN moderation_reasons text[] NOT NULL DEFAULT '{}',
+    ADD COLUMN reviewed_by        uuid REFERENCES teachers (id),
+    ADD COLUMN reviewed_at        timestamptz;
+
+CREATE INDEX public_messages_flagged_idx ON public_messages (created_at) WHERE moderation_status = 'flagged';
+
+CREATE TABLE school_blocklist_terms (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id  uuid NOT NULL REFERENCES schools (id) ON DELETE CASCADE,
+    term       text NOT NULL,
+    created_by uuid REFERENCES teachers (id) ON DELETE SET NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (school_id, term)
+);
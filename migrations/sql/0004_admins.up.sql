@@ -0,0 +1,8 @@
+CREATE TABLE admins (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    full_name  text NOT NULL,
+    email      text NOT NULL UNIQUE,
+    password   text NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS rollover_actions;
+DROP TABLE IF EXISTS rollover_runs;
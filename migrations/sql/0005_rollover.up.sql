@@ -0,0 +1,17 @@
+CREATE TABLE rollover_runs (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id  uuid NOT NULL REFERENCES schools (id),
+    from_year  integer NOT NULL,
+    to_year    integer NOT NULL,
+    created_by uuid REFERENCES admins (id) ON DELETE SET NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    undone_at  timestamptz
+);
+
+CREATE TABLE rollover_actions (
+    run_id    uuid NOT NULL REFERENCES rollover_runs (id) ON DELETE CASCADE,
+    position  integer NOT NULL,
+    action    text NOT NULL,
+    target_id uuid NOT NULL,
+    PRIMARY KEY (run_id, position)
+);
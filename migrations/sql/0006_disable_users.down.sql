@@ -0,0 +1,3 @@
+ALTER TABLE admins DROP COLUMN IF EXISTS disabled_at;
+ALTER TABLE teachers DROP COLUMN IF EXISTS disabled_at;
+ALTER TABLE students DROP COLUMN IF EXISTS disabled_at;
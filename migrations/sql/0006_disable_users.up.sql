@@ -0,0 +1,3 @@
+ALTER TABLE students ADD COLUMN disabled_at timestamptz;
+ALTER TABLE teachers ADD COLUMN disabled_at timestamptz;
+ALTER TABLE admins ADD COLUMN disabled_at timestamptz;
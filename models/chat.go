@@ -5,6 +5,7 @@ import "time"
 type PublicChat struct {
 	ID              string     `db:"id" json:"id"`
 	StudentID       *string    `db:"student_id" json:"student_id"`
+	ScsID           *string    `db:"scs_id" json:"scs_id"`
 	Title           *string    `db:"title" json:"title"`
 	Description     *string    `db:"description" json:"description"`
 	TeacherGlobalId *string    `db:"teacher_global_id" json:"teacher_global_id"`
@@ -21,4 +22,43 @@ type PublicChatMessage struct {
 	CreatedAt  time.Time  `db:"created_at" json:"created_at"`   // when question stored
 	AnsweredAt *time.Time `db:"answered_at" json:"answered_at"` // when AI responded
 	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
+
+	ModerationStatus  string     `db:"moderation_status" json:"moderation_status"`
+	ModerationReasons []string   `db:"moderation_reasons" json:"moderation_reasons"`
+	ReviewedBy        *string    `db:"reviewed_by" json:"reviewed_by"`
+	ReviewedAt        *time.Time `db:"reviewed_at" json:"reviewed_at"`
+}
+
+type AskQuestionRequest struct {
+	Question string `json:"question" binding:"notblank,max=2000"`
+	// Force skips the duplicate lookup and always sends the question to the answering engine.
+	Force bool `json:"force"`
+	// ReuseMessageID accepts the answer of a previously answered message in the same SCS.
+	ReuseMessageID string `json:"reuse_message_id" binding:"omitempty,uuid"`
+}
+
+// AskQuestionResponse either stores the question (Message) or, when Duplicate is set,
+// returns answered questions that look the same (Matches) without storing anything.
+type AskQuestionResponse struct {
+	Duplicate bool               `json:"duplicate"`
+	Matches   []SimilarQuestion  `json:"matches,omitempty"`
+	Message   *PublicChatMessage `json:"message,omitempty"`
+}
+
+// SimilarQuestion is an already answered question that looks like a newly asked one.
+type SimilarQuestion struct {
+	MessageID string  `db:"message_id" json:"message_id"`
+	ChatID    string  `db:"chat_id" json:"chat_id"`
+	Question  string  `db:"question" json:"question"`
+	Answer    string  `db:"answer" json:"answer"`
+	Score     float64 `db:"score" json:"score"`
+	Source    string  `db:"source" json:"source"` // "trigram" or "embedding"
+}
+
+type AnsweredQuestion struct {
+	MessageID string `db:"message_id"`
+	ChatID    string `db:"chat_id"`
+	ScsID     string `db:"scs_id"`
+	Question  string `db:"question"`
+	Answer    string `db:"answer"`
 }
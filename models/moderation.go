@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+const (
+	ModerationApproved = "approved"
+	ModerationFlagged  = "flagged"
+	ModerationRejected = "rejected"
+)
+
+type ModerationVerdict struct {
+	Status  string   `json:"status"`
+	Reasons []string `json:"reasons"`
+}
+
+// FlaggedMessage is a queued message together with the chat it was asked in.
+type FlaggedMessage struct {
+	PublicChatMessage
+	StudentID *string `db:"student_id" json:"student_id"`
+	ScsID     *string `db:"scs_id" json:"scs_id"`
+}
+
+type ModerationReviewRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=approve reject"`
+}
+
+type BlocklistTerm struct {
+	ID        string    `db:"id" json:"id"`
+	SchoolID  string    `db:"school_id" json:"school_id"`
+	Term      string    `db:"term" json:"term"`
+	CreatedBy *string   `db:"created_by" json:"created_by"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type BlocklistTermRequest struct {
+	Term string `json:"term" binding:"notblank,max=100"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// MaxPageLimit caps the limit query parameter of paginated lists.
+const MaxPageLimit = 100
+
+// Page selects a window of a list. A zero Limit returns everything from Offset on.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// TokenResponse is returned when a token is refreshed.
+type TokenResponse struct {
+	Token string `json:"token"`
+}
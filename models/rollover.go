@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+type RolloverRequest struct {
+	FromYear int `json:"from_year" binding:"required,min=2000,max=2100"`
+	// Ladder lists class ids or names from lowest to highest. Students in the last
+	// class graduate. Defaults to CLASS_LADDER when empty.
+	Ladder []string `json:"ladder" binding:"omitempty,dive,notblank"`
+	DryRun *bool    `json:"dry_run"`
+}
+
+type RolloverMapping struct {
+	ScsID   string `json:"scs_id"`
+	Class   string `json:"class"`
+	Subject string `json:"subject"`
+}
+
+type RolloverPromotion struct {
+	StudentID string `json:"student_id"`
+	FromScsID string `json:"from_scs_id"`
+	ToScsID   string `json:"to_scs_id,omitempty"`
+	FromClass string `json:"from_class"`
+	ToClass   string `json:"to_class,omitempty"`
+	Subject   string `json:"subject"`
+}
+
+type RolloverSkip struct {
+	StudentID string `json:"student_id"`
+	ScsID     string `json:"scs_id"`
+	Reason    string `json:"reason"`
+}
+
+// RolloverDiff describes what a rollover changes; a dry run returns it without writing.
+type RolloverDiff struct {
+	RunID          string              `json:"run_id,omitempty"`
+	DryRun         bool                `json:"dry_run"`
+	SchoolID       string              `json:"school_id"`
+	FromYear       int                 `json:"from_year"`
+	ToYear         int                 `json:"to_year"`
+	ClonedMappings []RolloverMapping   `json:"cloned_mappings"`
+	Promotions     []RolloverPromotion `json:"promotions"`
+	Graduations    []RolloverPromotion `json:"graduations"`
+	Skipped        []RolloverSkip      `json:"skipped"`
+}
+
+type RolloverRun struct {
+	ID        string     `db:"id" json:"id"`
+	SchoolID  string     `db:"school_id" json:"school_id"`
+	FromYear  int        `db:"from_year" json:"from_year"`
+	ToYear    int        `db:"to_year" json:"to_year"`
+	CreatedBy *string    `db:"created_by" json:"created_by"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UndoneAt  *time.Time `db:"undone_at" json:"undone_at"`
+}
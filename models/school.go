@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// NamedEntity is the shape shared by schools, classes and subjects.
+type NamedEntity struct {
+	ID        string    `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type NamedEntityRequest struct {
+	Name string `json:"name" binding:"notblank,max=100"`
+}
+
+type SCSMapping struct {
+	ID        string    `db:"id" json:"id"`
+	SchoolID  string    `db:"school_id" json:"school_id"`
+	ClassID   string    `db:"class_id" json:"class_id"`
+	SubjectID string    `db:"subject_id" json:"subject_id"`
+	Year      int       `db:"year" json:"year"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type SCSMappingRequest struct {
+	SchoolID  string `json:"school_id" binding:"required,uuid"`
+	ClassID   string `json:"class_id" binding:"required,uuid"`
+	SubjectID string `json:"subject_id" binding:"required,uuid"`
+	Year      int    `json:"year" binding:"required,min=2000,max=2100"`
+}
+
+type StudentSCSMapping struct {
+	ID        string    `db:"id" json:"id"`
+	StudentID string    `db:"student_id" json:"student_id"`
+	ScsID     string    `db:"scs_id" json:"scs_id"`
+	IsActive  bool      `db:"is_active" json:"is_active"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type EnrollmentRequest struct {
+	StudentID string `json:"student_id" binding:"required,uuid"`
+}
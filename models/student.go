@@ -11,11 +11,13 @@ type Student struct {
 	Password  *string `db:"password" json:"password"`
 }
 
+// StudentLoginRequest is the body of every login endpoint. The password is only
+// checked for presence so accounts created under an older policy can still log in.
 type StudentLoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
 }
 
 type StudentResetPasswordRequest struct {
-	Password string `json:"password"`
+	Password string `json:"password" binding:"required,password"`
 }
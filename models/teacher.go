@@ -1,17 +1,17 @@
 package models
 
 type Teacher struct {
-	ID        string `json:"id"`
-	TeacherID string `json:"teacher_id"`
-	FullName  string `json:"full_name"`
-	Email     string `json:"email"`
-	Phone     string `json:"phone_number"`
-	School    string `json:"school"`
-	DOB       string `json:"dob"`
-	Image     string `json:"image"`
-	Password  string `json:"password"`
+	ID        string `db:"id" json:"id"`
+	TeacherID string `db:"teacher_id" json:"teacher_id"`
+	FullName  string `db:"full_name" json:"full_name"`
+	Email     string `db:"email" json:"email"`
+	Phone     string `db:"phone" json:"phone_number"`
+	School    string `db:"school" json:"school"`
+	DOB       string `db:"dob" json:"dob"`
+	Image     string `db:"image" json:"image"`
+	Password  string `db:"password" json:"password"`
 }
 
 type TeacherResetPasswordRequest struct {
-	Password string `json:"password"`
+	Password string `json:"password" binding:"required,password"`
 }
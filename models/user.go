@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// UserAccount is the role independent view of a student, teacher or admin used by the CLI.
+type UserAccount struct {
+	ID         string     `db:"id" json:"id"`
+	Role       string     `db:"role" json:"role"`
+	ExternalID *string    `db:"external_id" json:"external_id"` // student_id or teacher_id
+	FullName   string     `db:"full_name" json:"full_name"`
+	Email      string     `db:"email" json:"email"`
+	DisabledAt *time.Time `db:"disabled_at" json:"disabled_at"`
+}
+
+type CreateUserRequest struct {
+	Role       string
+	ExternalID string
+	FullName   string
+	Email      string
+	Phone      string
+	School     string
+	Password   string
+}
+
+// IDResponse is returned by endpoints that delete or update a row without echoing it.
+type IDResponse struct {
+	ID string `json:"id"`
+}
+
+// TokenProfile are the claims of the caller's token.
+type TokenProfile struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// LoginResponse is returned by every login endpoint.
+type LoginResponse struct {
+	Token string    `json:"token"`
+	User  LoginUser `json:"user"`
+}
+
+type LoginUser struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
@@ -0,0 +1,93 @@
+package moderation
+
+import (
+	"backend/models"
+	"regexp"
+	"strings"
+)
+
+const (
+	ReasonProfanity       = "profanity"
+	ReasonSelfHarm        = "self_harm"
+	ReasonSchoolBlocklist = "school_blocklist"
+	ReasonEmail           = "pii_email"
+	ReasonPhone           = "pii_phone"
+	ReasonAddress         = "pii_address"
+)
+
+var defaultProfanity = []string{
+	"fuck", "fucking", "shit", "bitch", "bastard", "asshole", "dick", "cunt", "slut", "whore",
+}
+
+var defaultSelfHarm = []string{
+	"kill myself", "killing myself", "suicide", "suicidal", "self harm", "self-harm",
+	"hurt myself", "cut myself", "want to die", "end my life",
+}
+
+// Result is the outcome of moderating one question.
+type Result struct {
+	// Text is the question with personal data redacted; store this instead of the original.
+	Text    string
+	Verdict models.ModerationVerdict
+}
+
+// Flagged reports whether the question must be reviewed by a teacher before it is answered.
+func (r Result) Flagged() bool {
+	return r.Verdict.Status == models.ModerationFlagged
+}
+
+type Moderator struct {
+	profanity *regexp.Regexp
+	selfHarm  *regexp.Regexp
+}
+
+// NewModerator builds a moderator from the built-in keyword lists plus extra global terms.
+func NewModerator(extraProfanity []string) *Moderator {
+	return &Moderator{
+		profanity: keywordPattern(append(append([]string{}, defaultProfanity...), extraProfanity...)),
+		selfHarm:  keywordPattern(defaultSelfHarm),
+	}
+}
+
+// Check redacts personal data from text and flags it when it matches a keyword rule
+// or one of the school's blocklisted terms.
+func (m *Moderator) Check(text string, schoolTerms []string) Result {
+	result := Result{
+		Text:    text,
+		Verdict: models.ModerationVerdict{Status: models.ModerationApproved, Reasons: []string{}},
+	}
+
+	if m.selfHarm != nil && m.selfHarm.MatchString(text) {
+		result.flag(ReasonSelfHarm)
+	}
+	if m.profanity != nil && m.profanity.MatchString(text) {
+		result.flag(ReasonProfanity)
+	}
+	if blocklist := keywordPattern(schoolTerms); blocklist != nil && blocklist.MatchString(text) {
+		result.flag(ReasonSchoolBlocklist)
+	}
+
+	result.Text = result.redact(result.Text)
+	return result
+}
+
+func (r *Result) flag(reason string) {
+	r.Verdict.Status = models.ModerationFlagged
+	r.Verdict.Reasons = append(r.Verdict.Reasons, reason)
+}
+
+// keywordPattern compiles terms into one case-insensitive, word-bounded pattern.
+func keywordPattern(terms []string) *regexp.Regexp {
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+}
@@ -0,0 +1,86 @@
+package moderation_test
+
+import (
+	"backend/models"
+	"backend/moderation"
+	"slices"
+	"testing"
+)
+
+func TestRedaction(t *testing.T) {
+	m := moderation.NewModerator(nil)
+	cases := []struct {
+		name   string
+		text   string
+		want   string
+		reason string // "" when nothing is redacted
+	}{
+		{"email", "mail me at asha.k+hw@school.example.in please", "mail me at [redacted email] please", moderation.ReasonEmail},
+		{"mobile number", "call 98765 43210 after class", "call [redacted phone] after class", moderation.ReasonPhone},
+		{"international number", "my dad is on +91-98765-43210", "my dad is on [redacted phone]", moderation.ReasonPhone},
+		{"landline with brackets", "ring (080) 2345-6789", "ring [redacted phone]", moderation.ReasonPhone},
+		{"house number with a letter", "I live at 221B Baker Street", "I live at [redacted address]", moderation.ReasonAddress},
+		{"numbered street", "come to 42 MG Road tomorrow", "come to [redacted address] tomorrow", moderation.ReasonAddress},
+		{"flat and colony", "we moved to 14, Shanti Nagar", "we moved to [redacted address]", moderation.ReasonAddress},
+		{"abbreviated avenue", "drop it at 7 Park Ave. today", "drop it at [redacted address] today", moderation.ReasonAddress},
+
+		{"addition", "why is 3 + 4 = 7", "why is 3 + 4 = 7", ""},
+		{"polynomial", "factorise 2x^2 - 5x + 3", "factorise 2x^2 - 5x + 3", ""},
+		{"long sum", "is 12 + 345 = 357 correct", "is 12 + 345 = 357 correct", ""},
+		{"large product", "what is 123456 * 789", "what is 123456 * 789", ""},
+		{"decimal", "round 3.14159265 to two places", "round 3.14159265 to two places", ""},
+		{"coordinates", "plot (2, 3) and (4, 5)", "plot (2, 3) and (4, 5)", ""},
+		{"date range", "the war lasted 1939 - 1945", "the war lasted 1939 - 1945", ""},
+		{"quantity before a word", "3 apples and 4 oranges", "3 apples and 4 oranges", ""},
+		{"at sign in maths", "x @ y = 2", "x @ y = 2", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := m.Check(tc.text, nil)
+			if result.Text != tc.want {
+				t.Errorf("got %q, want %q", result.Text, tc.want)
+			}
+			reasons := result.Verdict.Reasons
+			if tc.reason == "" && len(reasons) != 0 || tc.reason != "" && !slices.Equal(reasons, []string{tc.reason}) {
+				t.Errorf("got reasons %v, want %q", reasons, tc.reason)
+			}
+			// personal data is redacted, not flagged
+			if result.Flagged() {
+				t.Errorf("got %s, want approved", result.Verdict.Status)
+			}
+		})
+	}
+}
+
+func TestCheckFlags(t *testing.T) {
+	m := moderation.NewModerator([]string{"homework app"})
+	cases := []struct {
+		name    string
+		text    string
+		terms   []string
+		reasons []string
+	}{
+		{"clean", "what is photosynthesis", nil, []string{}},
+		{"profanity", "this is SHIT", nil, []string{moderation.ReasonProfanity}},
+		{"inside a word", "mishit the ball, scunthorpe", nil, []string{}},
+		{"self harm", "sometimes I want to die", nil, []string{moderation.ReasonSelfHarm}},
+		{"extra global term", "which homework app is best", nil, []string{moderation.ReasonProfanity}},
+		{"school blocklist", "can I cheat in the exam", []string{" cheat ", ""}, []string{moderation.ReasonSchoolBlocklist}},
+		{"flag and redact", "shit, email me at a@b.co", nil, []string{moderation.ReasonProfanity, moderation.ReasonEmail}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := m.Check(tc.text, tc.terms)
+			if !slices.Equal(result.Verdict.Reasons, tc.reasons) {
+				t.Fatalf("got reasons %v, want %v", result.Verdict.Reasons, tc.reasons)
+			}
+			wantStatus := models.ModerationApproved
+			if len(tc.reasons) > 0 && tc.reasons[0] != moderation.ReasonEmail {
+				wantStatus = models.ModerationFlagged
+			}
+			if result.Verdict.Status != wantStatus {
+				t.Errorf("got %s, want %s", result.Verdict.Status, wantStatus)
+			}
+		})
+	}
+}
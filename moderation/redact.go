@@ -0,0 +1,47 @@
+package moderation
+
+import "regexp"
+
+var (
+	emailPattern   = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phonePattern   = regexp.MustCompile(`\+?\(?\d[\d\s().-]{8,}\d`)
+	addressPattern = regexp.MustCompile(`(?i)\b\d{1,5}[a-z]?[,/]?\s+(?:[a-z0-9.'-]+\s+){0,4}(?:street|st|road|rd|avenue|ave|lane|ln|nagar|colony|sector|block|apartment|apt|flat)\b\.?`)
+)
+
+const (
+	redactedEmail   = "[redacted email]"
+	redactedPhone   = "[redacted phone]"
+	redactedAddress = "[redacted address]"
+)
+
+func (r *Result) redact(text string) string {
+	if emailPattern.MatchString(text) {
+		text = emailPattern.ReplaceAllString(text, redactedEmail)
+		r.Verdict.Reasons = append(r.Verdict.Reasons, ReasonEmail)
+	}
+
+	phoneFound := false
+	text = phonePattern.ReplaceAllStringFunc(text, func(match string) string {
+		// require a realistic number of digits so maths like "12 + 345 = 357" survives
+		digits := 0
+		for _, ch := range match {
+			if ch >= '0' && ch <= '9' {
+				digits++
+			}
+		}
+		if digits < 10 || digits > 13 {
+			return match
+		}
+		phoneFound = true
+		return redactedPhone
+	})
+	if phoneFound {
+		r.Verdict.Reasons = append(r.Verdict.Reasons, ReasonPhone)
+	}
+
+	if addressPattern.MatchString(text) {
+		text = addressPattern.ReplaceAllString(text, redactedAddress)
+		r.Verdict.Reasons = append(r.Verdict.Reasons, ReasonAddress)
+	}
+	return text
+}
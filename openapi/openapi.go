@@ -0,0 +1,236 @@
+// Package openapi builds an OpenAPI 3 document from a list of operations whose request
+// and response bodies are given as Go values, so the spec follows the models it describes.
+package openapi
+
+import (
+	"backend/apperror"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operation documents one route. Request and Response are zero values of the body
+// types, e.g. models.StudentLoginRequest{}; Response is wrapped in the success envelope.
+type Operation struct {
+	Method  string
+	Path    string // gin syntax, e.g. /v1/students/chats/:id
+	Tag     string
+	Summary string
+	// Auth marks routes that need a bearer token.
+	Auth  bool
+	Query []Parameter
+	// Request is the JSON body. Upload documents a multipart form with a "file" field instead.
+	Request any
+	Upload  bool
+	// Response is the "data" of the success envelope; nil documents "data": null.
+	Response any
+	// Status is the success status, 200 when zero.
+	Status int
+	// Errors are statuses beyond those implied by the route: 400 for a body or path
+	// parameters, 401 and 403 with Auth, 404 with path parameters, and 500 always.
+	Errors []int
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+// QueryParam is a shorthand for an optional query parameter of the given schema type.
+func QueryParam(name string, schemaType string, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: schemaType}}
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]*pathItem `json:"paths"`
+	Components components                      `json:"components"`
+}
+
+type components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat"`
+}
+
+type pathItem struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	OperationID string                `json:"operationId"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+const errorSchema = "Error"
+
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+// Build generates the document for operations.
+func Build(info Info, operations []Operation) *Document {
+	s := newSchemas()
+	s.components[errorSchema] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"status": {Type: "boolean", Description: "Always false."},
+			"error":  s.of(apperror.Body{}),
+		},
+		Required: []string{"status", "error"},
+	}
+
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   map[string]map[string]*pathItem{},
+		Components: components{
+			Schemas: s.components,
+			SecuritySchemes: map[string]securityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+	for _, op := range operations {
+		path := pathParam.ReplaceAllString(op.Path, "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*pathItem{}
+		}
+		doc.Paths[path][strings.ToLower(op.Method)] = s.operation(op)
+	}
+	return doc
+}
+
+// Has reports whether method and the gin path are documented.
+func (d *Document) Has(method string, ginPath string) bool {
+	_, ok := d.Paths[pathParam.ReplaceAllString(ginPath, "{$1}")][strings.ToLower(method)]
+	return ok
+}
+
+func (s *schemas) operation(op Operation) *pathItem {
+	item := &pathItem{
+		Summary:     op.Summary,
+		OperationID: operationID(op),
+		Parameters:  append([]Parameter{}, op.Query...),
+		Responses:   map[string]response{},
+	}
+	if op.Tag != "" {
+		item.Tags = []string{op.Tag}
+	}
+	if op.Auth {
+		item.Security = []map[string][]string{{"bearerAuth": {}}}
+	}
+
+	params := pathParam.FindAllStringSubmatch(op.Path, -1)
+	for _, param := range params {
+		item.Parameters = append(item.Parameters, Parameter{
+			Name: param[1], In: "path", Required: true, Schema: &Schema{Type: "string", Format: "uuid"},
+		})
+	}
+
+	switch {
+	case op.Upload:
+		item.RequestBody = &requestBody{Required: true, Content: map[string]mediaType{
+			"multipart/form-data": {Schema: &Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{"file": {Type: "string", Format: "binary"}},
+				Required:   []string{"file"},
+			}},
+		}}
+	case op.Request != nil:
+		item.RequestBody = &requestBody{Required: true, Content: map[string]mediaType{
+			"application/json": {Schema: s.of(op.Request)},
+		}}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	item.Responses[strconv.Itoa(status)] = response{
+		Description: http.StatusText(status),
+		Content: map[string]mediaType{"application/json": {Schema: &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"status": {Type: "boolean", Description: "Always true."},
+				"data":   s.of(op.Response),
+			},
+			Required: []string{"status", "data"},
+		}}},
+	}
+
+	errors := append([]int{http.StatusInternalServerError}, op.Errors...)
+	if item.RequestBody != nil || len(params) > 0 {
+		errors = append(errors, http.StatusBadRequest)
+	}
+	if op.Auth {
+		errors = append(errors, http.StatusUnauthorized, http.StatusForbidden)
+	}
+	if len(params) > 0 {
+		errors = append(errors, http.StatusNotFound)
+	}
+	for _, code := range errors {
+		item.Responses[strconv.Itoa(code)] = response{
+			Description: http.StatusText(code),
+			Content: map[string]mediaType{"application/json": {Schema: &Schema{
+				Ref: "#/components/schemas/" + errorSchema,
+			}}},
+		}
+	}
+	return item
+}
+
+// operationID is derived from the method and path, e.g. getV1StudentsChatsById.
+func operationID(op Operation) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(op.Method))
+	for _, part := range strings.FieldsFunc(op.Path, func(r rune) bool { return r == '/' || r == '-' || r == '_' }) {
+		if strings.HasPrefix(part, ":") {
+			b.WriteString("By")
+			part = part[1:]
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// Operations lists the documented "METHOD /path" pairs in gin syntax, sorted.
+func (d *Document) Operations() []string {
+	var ops []string
+	for path, methods := range d.Paths {
+		for method := range methods {
+			ops = append(ops, strings.ToUpper(method)+" "+strings.NewReplacer("{", ":", "}", "").Replace(path))
+		}
+	}
+	sort.Strings(ops)
+	return ops
+}
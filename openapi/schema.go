@@ -0,0 +1,203 @@
+package openapi
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Schema is the subset of the OpenAPI 3.0 schema object the generator produces.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemas turns Go types into schemas, collecting named structs as components.
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+// of returns the schema of v's type; named structs become a $ref to a component.
+func (s *schemas) of(v any) *Schema {
+	if v == nil {
+		return &Schema{Nullable: true}
+	}
+	return s.forType(reflect.TypeOf(v))
+}
+
+func (s *schemas) forType(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Pointer:
+		schema := s.forType(t.Elem())
+		if schema.Ref != "" {
+			// siblings of $ref are ignored in 3.0, so nullable refs are not expressed
+			return schema
+		}
+		schema.Nullable = true
+		return schema
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: s.forType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.forType(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		name := s.name(t)
+		if _, ok := s.components[name]; !ok {
+			s.components[name] = &Schema{} // placeholder for recursive types
+			s.components[name] = s.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	// interfaces such as any accept every value
+	return &Schema{}
+}
+
+// name is the type name, prefixed with its package outside models so roster.Report
+// and a future models.Report do not collide.
+func (s *schemas) name(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+	if pkg != "models" {
+		runes := []rune(pkg)
+		runes[0] = unicode.ToUpper(runes[0])
+		name = string(runes) + name
+	}
+	s.names[t] = name
+	return name
+}
+
+// object describes a struct from its json tags. Request structs (those with binding
+// tags) list the fields their tags require; responses list every field not marked omitempty.
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	request := hasBindingTags(t)
+	s.addFields(schema, t, request)
+	return schema
+}
+
+func (s *schemas) addFields(schema *Schema, t reflect.Type, request bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			s.addFields(schema, field.Type, request)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := s.forType(field.Type)
+		rules := strings.Split(field.Tag.Get("binding"), ",")
+		applyRules(property, field.Type, rules)
+		schema.Properties[name] = property
+
+		required := !strings.Contains(options, "omitempty")
+		if request {
+			required = slices.Contains(rules, "required") || slices.Contains(rules, "notblank")
+		}
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// applyRules copies the validator tags clients can check themselves into the schema.
+func applyRules(schema *Schema, t reflect.Type, rules []string) {
+	for _, rule := range rules {
+		if rule == "dive" {
+			// the remaining rules apply to the elements
+			return
+		}
+		tag, param, _ := strings.Cut(rule, "=")
+		switch tag {
+		case "email":
+			schema.Format = "email"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		case "password":
+			schema.Format = "password"
+			schema.Description = "At least 8 characters including a letter and a digit."
+		case "notblank":
+			one := 1
+			schema.MinLength = &one
+		case "oneof":
+			schema.Enum = strings.Fields(param)
+		case "min", "max":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			if t.Kind() == reflect.String {
+				if tag == "min" {
+					schema.MinLength = &n
+				} else {
+					schema.MaxLength = &n
+				}
+				continue
+			}
+			f := float64(n)
+			if tag == "min" {
+				schema.Minimum = &f
+			} else {
+				schema.Maximum = &f
+			}
+		}
+	}
+}
+
+func hasBindingTags(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup("binding"); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+//go:embed ui/index.html
+var indexHTML string
+
+var indexTemplate = template.Must(template.New("index").Parse(indexHTML))
+
+// UI serves Swagger UI from the binary, pointed at specURL. Register it on a
+// wildcard route such as /docs/*filepath.
+func UI(specURL string) gin.HandlerFunc {
+	var index bytes.Buffer
+	if err := indexTemplate.Execute(&index, struct{ SpecURL string }{specURL}); err != nil {
+		panic(err)
+	}
+	assets := http.FileServer(http.FS(swaggerFiles.FS))
+
+	return func(ctx *gin.Context) {
+		file := strings.TrimPrefix(ctx.Param("filepath"), "/")
+		if file == "" || file == "index.html" {
+			ctx.Data(http.StatusOK, "text/html; charset=utf-8", index.Bytes())
+			return
+		}
+		request := ctx.Request.Clone(ctx.Request.Context())
+		request.URL.Path = "/" + file
+		assets.ServeHTTP(ctx.Writer, request)
+	}
+}
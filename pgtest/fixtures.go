@@ -0,0 +1,44 @@
+package pgtest
+
+// FixtureSchool loads fixtures/school.sql: Demo School with Class 8 (2024) and Class 9 (2025)
+// mappings for Mathematics and Science, a student promoted between them, a second student
+// with no enrollments, a teacher for each school, an admin, and one chat with an answered
+// and a flagged question. Every account's password is FixturePassword.
+const FixtureSchool = "school"
+
+const FixturePassword = "secret-123"
+
+// Ids of the FixtureSchool rows.
+const (
+	DemoSchoolID  = "10000000-0000-4000-8000-000000000001"
+	OtherSchoolID = "10000000-0000-4000-8000-000000000002"
+
+	Class8ID = "20000000-0000-4000-8000-000000000008"
+	Class9ID = "20000000-0000-4000-8000-000000000009"
+
+	MathematicsID = "30000000-0000-4000-8000-000000000001"
+	ScienceID     = "30000000-0000-4000-8000-000000000002"
+
+	// SCS mappings of Demo School
+	Maths2024SCSID   = "40000000-0000-4000-8000-000000000001"
+	Science2024SCSID = "40000000-0000-4000-8000-000000000002"
+	Maths2025SCSID   = "40000000-0000-4000-8000-000000000003"
+	Science2025SCSID = "40000000-0000-4000-8000-000000000004"
+
+	// AshaID is enrolled in the 2024 mappings (inactive) and the 2025 ones (active).
+	AshaID  = "50000000-0000-4000-8000-000000000001"
+	KiranID = "50000000-0000-4000-8000-000000000002"
+
+	// RaviID teaches at Demo School, NilaID at Other School.
+	RaviID = "60000000-0000-4000-8000-000000000001"
+	NilaID = "60000000-0000-4000-8000-000000000002"
+
+	MeeraAdminID = "70000000-0000-4000-8000-000000000001"
+
+	// FractionsChatID is Asha's chat in Maths2025SCSID.
+	FractionsChatID   = "80000000-0000-4000-8000-000000000001"
+	AnsweredMessageID = "90000000-0000-4000-8000-000000000001"
+	FlaggedMessageID  = "90000000-0000-4000-8000-000000000002"
+
+	AnsweredQuestion = "How do I add two fractions?"
+)
@@ -0,0 +1,46 @@
+-- See FixtureSchool in fixtures.go; the ids here must match the constants there.
+
+INSERT INTO schools (id, name) VALUES
+    ('10000000-0000-4000-8000-000000000001', 'Demo School'),
+    ('10000000-0000-4000-8000-000000000002', 'Other School');
+
+INSERT INTO classes (id, name) VALUES
+    ('20000000-0000-4000-8000-000000000008', 'Class 8'),
+    ('20000000-0000-4000-8000-000000000009', 'Class 9');
+
+INSERT INTO subjects (id, name) VALUES
+    ('30000000-0000-4000-8000-000000000001', 'Mathematics'),
+    ('30000000-0000-4000-8000-000000000002', 'Science');
+
+INSERT INTO school_class_subject_mapping (id, school_id, class_id, subject_id, year) VALUES
+    ('40000000-0000-4000-8000-000000000001', '10000000-0000-4000-8000-000000000001', '20000000-0000-4000-8000-000000000008', '30000000-0000-4000-8000-000000000001', 2024),
+    ('40000000-0000-4000-8000-000000000002', '10000000-0000-4000-8000-000000000001', '20000000-0000-4000-8000-000000000008', '30000000-0000-4000-8000-000000000002', 2024),
+    ('40000000-0000-4000-8000-000000000003', '10000000-0000-4000-8000-000000000001', '20000000-0000-4000-8000-000000000009', '30000000-0000-4000-8000-000000000001', 2025),
+    ('40000000-0000-4000-8000-000000000004', '10000000-0000-4000-8000-000000000001', '20000000-0000-4000-8000-000000000009', '30000000-0000-4000-8000-000000000002', 2025);
+
+INSERT INTO students (id, student_id, full_name, email, phone_number, password) VALUES
+    ('50000000-0000-4000-8000-000000000001', 'S-1', 'Asha', 'asha@demo.local', '9000000001', 'secret-123'),
+    ('50000000-0000-4000-8000-000000000002', 'S-2', 'Kiran', 'kiran@demo.local', '9000000002', 'secret-123');
+
+INSERT INTO teachers (id, teacher_id, full_name, email, school, password) VALUES
+    ('60000000-0000-4000-8000-000000000001', 'T-1', 'Ravi', 'ravi@demo.local', '10000000-0000-4000-8000-000000000001', 'secret-123'),
+    ('60000000-0000-4000-8000-000000000002', 'T-2', 'Nila', 'nila@demo.local', '10000000-0000-4000-8000-000000000002', 'secret-123');
+
+INSERT INTO admins (id, full_name, email, password) VALUES
+    ('70000000-0000-4000-8000-000000000001', 'Meera', 'meera@demo.local', 'secret-123');
+
+INSERT INTO student_scs_mapping (student_id, scs_id, is_active) VALUES
+    ('50000000-0000-4000-8000-000000000001', '40000000-0000-4000-8000-000000000001', false),
+    ('50000000-0000-4000-8000-000000000001', '40000000-0000-4000-8000-000000000002', false),
+    ('50000000-0000-4000-8000-000000000001', '40000000-0000-4000-8000-000000000003', true),
+    ('50000000-0000-4000-8000-000000000001', '40000000-0000-4000-8000-000000000004', true);
+
+INSERT INTO public_chats (id, student_id, scs_id, title) VALUES
+    ('80000000-0000-4000-8000-000000000001', '50000000-0000-4000-8000-000000000001', '40000000-0000-4000-8000-000000000003', 'Fractions');
+
+INSERT INTO public_messages (id, chat_id, question, answer, answered_at, created_at, moderation_status, moderation_reasons) VALUES
+    ('90000000-0000-4000-8000-000000000001', '80000000-0000-4000-8000-000000000001',
+     'How do I add two fractions?', 'Rewrite both over a common denominator, then add the numerators.',
+     now() - interval '2 hours', now() - interval '2 hours', 'approved', '{}'),
+    ('90000000-0000-4000-8000-000000000002', '80000000-0000-4000-8000-000000000001',
+     'this homework is shit', NULL, NULL, now() - interval '1 hour', 'flagged', '{profanity}');
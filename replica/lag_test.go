@@ -0,0 +1,36 @@
+package replica
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLagOf(t *testing.T) {
+	now := time.Now()
+	yes, no := true, false
+	hourAgo, minuteAgo := now.Add(-time.Hour), now.Add(-time.Minute)
+	cases := []struct {
+		name       string
+		inRecovery bool
+		caughtUp   *bool
+		replayedAt *time.Time
+		want       time.Duration
+		wantErr    error
+	}{
+		{"primary", false, nil, nil, 0, nil},
+		{"caught up on an idle primary", true, &yes, &hourAgo, 0, nil},
+		// the receiver stopped with receive and replay positions equal, then the primary wrote
+		{"disconnected receiver", true, &no, &minuteAgo, time.Minute, nil},
+		{"behind without replaying anything", true, &no, nil, 0, errNeverReplayed},
+		{"unknown replay position", true, nil, &minuteAgo, time.Minute, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := lagOf(tc.inRecovery, tc.caughtUp, tc.replayedAt, now)
+			if got != tc.want || !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got %s, %v, want %s, %v", got, err, tc.want, tc.wantErr)
+			}
+		})
+	}
+}
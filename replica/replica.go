@@ -0,0 +1,214 @@
+// Package replica sends read-only queries to streaming replicas and everything else to
+// the primary. Replicas whose replication lag exceeds the limit are skipped, and so are
+// all replicas for a user who wrote recently, so they read their own writes.
+package replica
+
+import (
+	"backend/config"
+	"backend/metrics"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// positionQuery reads how far a replica has replayed, given the primary's current WAL
+// position. A replica that replayed everything the primary has written is current even
+// when the primary has been idle for a while; one whose WAL receiver is disconnected
+// falls behind as soon as the primary writes again.
+const positionQuery = `
+	SELECT pg_is_in_recovery(), pg_last_wal_replay_lsn() >= $1::pg_lsn, pg_last_xact_replay_timestamp()
+`
+
+var errNeverReplayed = errors.New("replica is behind and has not replayed any transaction")
+
+// Lag asks replica how far it is behind primary.
+func Lag(ctx context.Context, primary *pgxpool.Pool, replica *pgxpool.Pool) (time.Duration, error) {
+	var primaryLSN string
+	if err := primary.QueryRow(ctx, `SELECT pg_current_wal_lsn()::text`).Scan(&primaryLSN); err != nil {
+		return 0, fmt.Errorf("read primary WAL position: %w", err)
+	}
+	var inRecovery bool
+	var caughtUp *bool
+	var replayedAt *time.Time
+	if err := replica.QueryRow(ctx, positionQuery, primaryLSN).Scan(&inRecovery, &caughtUp, &replayedAt); err != nil {
+		return 0, err
+	}
+	return lagOf(inRecovery, caughtUp, replayedAt, time.Now())
+}
+
+// lagOf is the lag of a replica that replayed up to the primary's position (caughtUp)
+// or, behind it, last replayed a transaction committed at replayedAt.
+func lagOf(inRecovery bool, caughtUp *bool, replayedAt *time.Time, now time.Time) (time.Duration, error) {
+	switch {
+	case !inRecovery, caughtUp != nil && *caughtUp:
+		return 0, nil
+	case replayedAt == nil:
+		return 0, errNeverReplayed
+	}
+	return now.Sub(*replayedAt), nil
+}
+
+// stickySweep is how many sticky users may accumulate before expired ones are dropped.
+const stickySweep = 1024
+
+type node struct {
+	name    string
+	pool    *pgxpool.Pool
+	usable  atomic.Bool
+	lagging atomic.Bool // last check succeeded but the lag exceeded the limit
+}
+
+// Router picks the pool for each query. The zero number of replicas is valid: every
+// read then goes to the primary.
+type Router struct {
+	primary  *pgxpool.Pool
+	replicas []*node
+	maxLag   time.Duration
+	window   time.Duration
+	next     atomic.Uint64
+
+	// Probe measures the lag of a replica; Lag against the primary unless replaced in tests.
+	Probe func(ctx context.Context, pool *pgxpool.Pool) (time.Duration, error)
+
+	mu     sync.Mutex
+	writes map[string]time.Time // user id -> time of their last write
+}
+
+// NewRouter routes reads to replicas lagging at most maxLag behind primary, except for
+// users who wrote within window. Replicas are unused until the first CheckLag.
+func NewRouter(primary *pgxpool.Pool, replicas []*pgxpool.Pool, maxLag time.Duration, window time.Duration) *Router {
+	r := &Router{
+		primary: primary,
+		maxLag:  maxLag,
+		window:  window,
+		writes:  map[string]time.Time{},
+	}
+	r.Probe = func(ctx context.Context, pool *pgxpool.Pool) (time.Duration, error) {
+		return Lag(ctx, primary, pool)
+	}
+	for _, pool := range replicas {
+		cfg := pool.Config().ConnConfig
+		r.replicas = append(r.replicas, &node{name: net.JoinHostPort(cfg.Host, strconv.Itoa(int(cfg.Port))), pool: pool})
+	}
+	return r
+}
+
+// Primary is the pool for writes and for reads that must see the latest data.
+func (r *Router) Primary() *pgxpool.Pool {
+	return r.primary
+}
+
+type primaryKey struct{}
+
+// WithPrimary marks ctx so Reader returns the primary.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// Reader is the pool for a read-only query that tolerates replication lag: the next
+// usable replica in turn, or the primary when ctx was marked with WithPrimary or no
+// replica is usable.
+func (r *Router) Reader(ctx context.Context) *pgxpool.Pool {
+	if pinned, _ := ctx.Value(primaryKey{}).(bool); !pinned && len(r.replicas) > 0 {
+		start := r.next.Add(1)
+		for i := range r.replicas {
+			n := r.replicas[(start+uint64(i))%uint64(len(r.replicas))]
+			if n.usable.Load() {
+				metrics.DBReads.WithLabelValues("replica").Inc()
+				return n.pool
+			}
+		}
+	}
+	metrics.DBReads.WithLabelValues("primary").Inc()
+	return r.primary
+}
+
+// Wrote records that userID changed data, keeping their reads on the primary for the
+// window. Stickiness is per process; replicas rarely lag long enough for a user's next
+// request to reach another instance first.
+func (r *Router) Wrote(userID string) {
+	if userID == "" || len(r.replicas) == 0 {
+		return
+	}
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.writes) >= stickySweep {
+		for id, at := range r.writes {
+			if now.Sub(at) >= r.window {
+				delete(r.writes, id)
+			}
+		}
+	}
+	r.writes[userID] = now
+}
+
+// Sticky reports whether userID wrote within the window.
+func (r *Router) Sticky(userID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	at, ok := r.writes[userID]
+	if ok && time.Since(at) >= r.window {
+		delete(r.writes, userID)
+		return false
+	}
+	return ok
+}
+
+// CheckLag probes every replica once and updates which of them Reader may use.
+func (r *Router) CheckLag(ctx context.Context) {
+	for _, n := range r.replicas {
+		lag, err := r.Probe(ctx, n.pool)
+		usable := err == nil && lag <= r.maxLag
+		if err != nil {
+			metrics.ReplicaLag.WithLabelValues(n.name).Set(-1)
+		} else {
+			metrics.ReplicaLag.WithLabelValues(n.name).Set(lag.Seconds())
+		}
+
+		lagging := err == nil && !usable
+		wasUsable, wasLagging := n.usable.Swap(usable), n.lagging.Swap(lagging)
+		switch {
+		case usable && !wasUsable:
+			config.GetLogger().Info("replica in use", zap.String("replica", n.name), zap.Duration("lag", lag))
+		case err != nil && (wasUsable || wasLagging):
+			config.GetLogger().Warn("replica_unreachable", zap.String("replica", n.name), zap.Error(err))
+		case lagging && !wasLagging:
+			config.GetLogger().Warn("replica_lagging", zap.String("replica", n.name), zap.Duration("lag", lag), zap.Duration("max_lag", r.maxLag))
+		}
+	}
+}
+
+// Watch runs CheckLag every interval until ctx is done.
+func (r *Router) Watch(ctx context.Context, interval time.Duration) {
+	if len(r.replicas) == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		checkCtx, cancel := context.WithTimeout(ctx, interval)
+		r.CheckLag(checkCtx)
+		cancel()
+	}
+}
+
+// Close closes the replica pools; the primary belongs to the caller.
+func (r *Router) Close() {
+	for _, n := range r.replicas {
+		n.pool.Close()
+	}
+}
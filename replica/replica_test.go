@@ -0,0 +1,121 @@
+package replica_test
+
+import (
+	"backend/config"
+	"backend/replica"
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestMain(m *testing.M) {
+	config.InitLogger()
+	if err := config.ConfigureLogger("fatal", "json"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// pool returns a pool that never connects; the tests only compare which one is picked.
+func pool(t *testing.T, host string) *pgxpool.Pool {
+	t.Helper()
+	p, err := pgxpool.New(context.Background(), "postgres://app@"+host+":5432/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(p.Close)
+	return p
+}
+
+// lags is a fake replica.Lag answering from a table keyed by pool.
+type lags struct {
+	mu     sync.Mutex
+	byPool map[*pgxpool.Pool]time.Duration
+	down   map[*pgxpool.Pool]bool
+}
+
+func (l *lags) probe(ctx context.Context, pool *pgxpool.Pool) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.down[pool] {
+		return 0, errors.New("connection refused")
+	}
+	return l.byPool[pool], nil
+}
+
+func TestReaderSkipsLaggingAndUnreachableReplicas(t *testing.T) {
+	primary, fresh, behind, down := pool(t, "primary"), pool(t, "fresh"), pool(t, "behind"), pool(t, "down")
+	router := replica.NewRouter(primary, []*pgxpool.Pool{fresh, behind, down}, 5*time.Second, time.Minute)
+	fake := &lags{
+		byPool: map[*pgxpool.Pool]time.Duration{fresh: time.Second, behind: time.Minute},
+		down:   map[*pgxpool.Pool]bool{down: true},
+	}
+	router.Probe = fake.probe
+	ctx := context.Background()
+
+	if got := router.Reader(ctx); got != primary {
+		t.Fatal("replicas were used before their lag was checked")
+	}
+
+	router.CheckLag(ctx)
+	for i := 0; i < 6; i++ {
+		if got := router.Reader(ctx); got != fresh {
+			t.Fatalf("read %d: got %s, want the only current replica", i, got.Config().ConnConfig.Host)
+		}
+	}
+	if got := router.Reader(replica.WithPrimary(ctx)); got != primary {
+		t.Fatal("WithPrimary read went to a replica")
+	}
+
+	// behind catches up, fresh goes away
+	fake.byPool[behind] = 0
+	fake.down[fresh] = true
+	router.CheckLag(ctx)
+	if got := router.Reader(ctx); got != behind {
+		t.Fatalf("got %s, want the replica that caught up", got.Config().ConnConfig.Host)
+	}
+
+	fake.down[behind] = true
+	router.CheckLag(ctx)
+	if got := router.Reader(ctx); got != primary {
+		t.Fatal("read went to a replica although none is usable")
+	}
+}
+
+func TestReaderSpreadsReadsOverReplicas(t *testing.T) {
+	primary, a, b := pool(t, "primary"), pool(t, "a"), pool(t, "b")
+	router := replica.NewRouter(primary, []*pgxpool.Pool{a, b}, time.Second, time.Minute)
+	router.Probe = (&lags{}).probe
+	router.CheckLag(context.Background())
+
+	seen := map[*pgxpool.Pool]int{}
+	for i := 0; i < 10; i++ {
+		seen[router.Reader(context.Background())]++
+	}
+	if seen[a] != 5 || seen[b] != 5 {
+		t.Fatalf("got %d reads on a and %d on b, want 5 each", seen[a], seen[b])
+	}
+}
+
+func TestStickyExpiresAfterWindow(t *testing.T) {
+	router := replica.NewRouter(pool(t, "primary"), []*pgxpool.Pool{pool(t, "replica")}, time.Second, 50*time.Millisecond)
+
+	router.Wrote("student-1")
+	if !router.Sticky("student-1") || router.Sticky("student-2") {
+		t.Fatal("only the student who wrote should be sticky")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if router.Sticky("student-1") {
+		t.Fatal("student still sticky after the window")
+	}
+
+	router.Wrote("")
+	if router.Sticky("") {
+		t.Fatal("anonymous writes must not pin anonymous reads")
+	}
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"backend/cache"
+	"backend/handlers"
+	"backend/models"
+	"backend/replica"
+	"context"
+	"time"
+)
+
+// Cache namespaces. Names appear inside the SCS details, so renaming or deleting a
+// school, class or subject drops the SCS namespace as well.
+const (
+	cacheStudents = "student"
+	cacheSCS      = "scs"
+	cacheNamed    = "named:"
+)
+
+// CacheTTLs are how long each kind of cached read lives when no write invalidates it.
+type CacheTTLs struct {
+	Profile time.Duration
+	SCS     time.Duration
+	Names   time.Duration
+}
+
+// Cached serves student profiles, SCS mappings and school, class and subject names
+// from store and invalidates them on the writes that change them. Roster imports only
+// add new students, whose reads cannot be cached yet, so the importer is left as is.
+// Loads read the primary: a lagging replica could cache again the row an invalidation
+// just dropped, and keep it stale for the whole ttl.
+func Cached(set Set, store *cache.Store, ttls CacheTTLs) Set {
+	set.Students = &cachedStudents{Students: set.Students, store: store, ttl: ttls.Profile}
+	set.SCS = &cachedSCS{SCS: set.SCS, store: store, ttl: ttls.SCS}
+	set.Admin = &cachedAdmin{Admin: set.Admin, store: store, ttl: ttls.Names}
+	set.Rollovers = &cachedRollovers{Rollovers: set.Rollovers, store: store}
+	return set
+}
+
+type cachedStudents struct {
+	Students
+	store *cache.Store
+	ttl   time.Duration
+}
+
+func (c *cachedStudents) FetchStudentByID(ctx context.Context, id string) (models.Student, error) {
+	return cache.Fetch(ctx, c.store, c.store.Key(ctx, cacheStudents, id), c.ttl, func(ctx context.Context) (models.Student, error) {
+		return c.Students.FetchStudentByID(replica.WithPrimary(ctx), id)
+	})
+}
+
+type cachedSCS struct {
+	SCS
+	store *cache.Store
+	ttl   time.Duration
+}
+
+func (c *cachedSCS) FetchSCSDetailsByUserID(ctx context.Context, studentID string) ([]models.YearWiseDetails, error) {
+	return cache.Fetch(ctx, c.store, c.store.Key(ctx, cacheSCS, studentID), c.ttl, func(ctx context.Context) ([]models.YearWiseDetails, error) {
+		return c.SCS.FetchSCSDetailsByUserID(replica.WithPrimary(ctx), studentID)
+	})
+}
+
+type cachedAdmin struct {
+	Admin
+	store *cache.Store
+	ttl   time.Duration
+}
+
+func (c *cachedAdmin) FetchNamed(ctx context.Context, t handlers.NamedTable) ([]models.NamedEntity, error) {
+	return cache.Fetch(ctx, c.store, c.store.Key(ctx, cacheNamed+t.Table, "all"), c.ttl, func(ctx context.Context) ([]models.NamedEntity, error) {
+		return c.Admin.FetchNamed(replica.WithPrimary(ctx), t)
+	})
+}
+
+func (c *cachedAdmin) FetchNamedByID(ctx context.Context, t handlers.NamedTable, id string) (models.NamedEntity, error) {
+	return cache.Fetch(ctx, c.store, c.store.Key(ctx, cacheNamed+t.Table, id), c.ttl, func(ctx context.Context) (models.NamedEntity, error) {
+		return c.Admin.FetchNamedByID(replica.WithPrimary(ctx), t, id)
+	})
+}
+
+func (c *cachedAdmin) CreateNamed(ctx context.Context, t handlers.NamedTable, name string) (models.NamedEntity, error) {
+	entity, err := c.Admin.CreateNamed(ctx, t, name)
+	if err == nil {
+		c.store.Invalidate(ctx, cacheNamed+t.Table, "all")
+	}
+	return entity, err
+}
+
+func (c *cachedAdmin) UpdateNamed(ctx context.Context, t handlers.NamedTable, id string, name string) (models.NamedEntity, error) {
+	entity, err := c.Admin.UpdateNamed(ctx, t, id, name)
+	if err == nil {
+		c.renamed(ctx, t, id)
+	}
+	return entity, err
+}
+
+func (c *cachedAdmin) DeleteNamed(ctx context.Context, t handlers.NamedTable, id string) error {
+	err := c.Admin.DeleteNamed(ctx, t, id)
+	if err == nil {
+		c.renamed(ctx, t, id)
+	}
+	return err
+}
+
+func (c *cachedAdmin) renamed(ctx context.Context, t handlers.NamedTable, id string) {
+	c.store.Invalidate(ctx, cacheNamed+t.Table, "all")
+	c.store.Invalidate(ctx, cacheNamed+t.Table, id)
+	c.store.Bump(ctx, cacheSCS)
+}
+
+func (c *cachedAdmin) UpdateSCSMapping(ctx context.Context, id string, req models.SCSMappingRequest) (models.SCSMapping, error) {
+	mapping, err := c.Admin.UpdateSCSMapping(ctx, id, req)
+	if err == nil {
+		c.store.Bump(ctx, cacheSCS)
+	}
+	return mapping, err
+}
+
+func (c *cachedAdmin) DeleteSCSMapping(ctx context.Context, id string) error {
+	err := c.Admin.DeleteSCSMapping(ctx, id)
+	if err == nil {
+		c.store.Bump(ctx, cacheSCS)
+	}
+	return err
+}
+
+func (c *cachedAdmin) EnrollStudent(ctx context.Context, scsID string, studentID string) (models.StudentSCSMapping, error) {
+	enrollment, err := c.Admin.EnrollStudent(ctx, scsID, studentID)
+	if err == nil {
+		c.store.Invalidate(ctx, cacheSCS, studentID)
+	}
+	return enrollment, err
+}
+
+func (c *cachedAdmin) UnenrollStudent(ctx context.Context, scsID string, studentID string) (models.StudentSCSMapping, error) {
+	enrollment, err := c.Admin.UnenrollStudent(ctx, scsID, studentID)
+	if err == nil {
+		c.store.Invalidate(ctx, cacheSCS, studentID)
+	}
+	return enrollment, err
+}
+
+// cachedRollovers drops every SCS mapping, since a rollover enrolls and unenrolls whole classes.
+type cachedRollovers struct {
+	Rollovers
+	store *cache.Store
+}
+
+func (c *cachedRollovers) Run(ctx context.Context, schoolID string, fromYear int, ladder []string, createdBy string, dryRun bool) (models.RolloverDiff, error) {
+	diff, err := c.Rollovers.Run(ctx, schoolID, fromYear, ladder, createdBy, dryRun)
+	if err == nil && !dryRun {
+		c.store.Bump(ctx, cacheSCS)
+	}
+	return diff, err
+}
+
+func (c *cachedRollovers) Undo(ctx context.Context, runID string) (models.RolloverRun, error) {
+	run, err := c.Rollovers.Undo(ctx, runID)
+	if err == nil {
+		c.store.Bump(ctx, cacheSCS)
+	}
+	return run, err
+}
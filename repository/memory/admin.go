@@ -0,0 +1,431 @@
+package memory
+
+import (
+	"backend/handlers"
+	"backend/models"
+	"backend/repository"
+	"backend/rollover"
+	"backend/roster"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	_ repository.RosterImporter = (*Importer)(nil)
+	_ repository.Rollovers      = (*Rollovers)(nil)
+)
+
+// ------------------
+// Schools, classes and subjects
+// ------------------
+
+func (s *Store) name(table string, id string) string {
+	entity, _ := s.namedByID(table, id)
+	return entity.Name
+}
+
+func (s *Store) namedByID(table string, id string) (models.NamedEntity, bool) {
+	for _, entity := range s.named[table] {
+		if entity.ID == id {
+			return entity, true
+		}
+	}
+	return models.NamedEntity{}, false
+}
+
+func (s *Store) FetchNamed(ctx context.Context, t handlers.NamedTable) ([]models.NamedEntity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entities := append([]models.NamedEntity{}, s.named[t.Table]...)
+	sort.Slice(entities, func(i, j int) bool { return entities[i].Name < entities[j].Name })
+	return entities, nil
+}
+
+func (s *Store) FetchNamedByID(ctx context.Context, t handlers.NamedTable, id string) (models.NamedEntity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entity, ok := s.namedByID(t.Table, id)
+	if !ok {
+		return models.NamedEntity{}, pgx.ErrNoRows
+	}
+	return entity, nil
+}
+
+func (s *Store) CreateNamed(ctx context.Context, t handlers.NamedTable, name string) (models.NamedEntity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entity := range s.named[t.Table] {
+		if entity.Name == name {
+			return models.NamedEntity{}, errUniqueViolation
+		}
+	}
+	now := time.Now()
+	entity := models.NamedEntity{ID: newID(), Name: name, CreatedAt: now, UpdatedAt: now}
+	s.named[t.Table] = append(s.named[t.Table], entity)
+	return entity, nil
+}
+
+func (s *Store) UpdateNamed(ctx context.Context, t handlers.NamedTable, id string, name string) (models.NamedEntity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entities := s.named[t.Table]
+	for _, entity := range entities {
+		if entity.Name == name && entity.ID != id {
+			return models.NamedEntity{}, errUniqueViolation
+		}
+	}
+	for i := range entities {
+		if entities[i].ID == id {
+			entities[i].Name = name
+			entities[i].UpdatedAt = time.Now()
+			return entities[i], nil
+		}
+	}
+	return models.NamedEntity{}, pgx.ErrNoRows
+}
+
+func (s *Store) DeleteNamed(ctx context.Context, t handlers.NamedTable, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, mapping := range s.mappings {
+		if mapping.SchoolID == id || mapping.ClassID == id || mapping.SubjectID == id {
+			return errForeignKeyViolation
+		}
+	}
+	entities := s.named[t.Table]
+	for i, entity := range entities {
+		if entity.ID == id {
+			s.named[t.Table] = append(entities[:i], entities[i+1:]...)
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+// ------------------
+// SCS mappings
+// ------------------
+
+func (s *Store) mapping(id string) (models.SCSMapping, bool) {
+	for _, mapping := range s.mappings {
+		if mapping.ID == id {
+			return mapping, true
+		}
+	}
+	return models.SCSMapping{}, false
+}
+
+// checkSCSMapping enforces the foreign keys and the school+class+subject+year uniqueness.
+func (s *Store) checkSCSMapping(id string, req models.SCSMappingRequest) error {
+	_, school := s.namedByID(handlers.SchoolsTable.Table, req.SchoolID)
+	_, class := s.namedByID(handlers.ClassesTable.Table, req.ClassID)
+	_, subject := s.namedByID(handlers.SubjectsTable.Table, req.SubjectID)
+	if !school || !class || !subject {
+		return errForeignKeyViolation
+	}
+	for _, mapping := range s.mappings {
+		if mapping.ID != id && mapping.SchoolID == req.SchoolID && mapping.ClassID == req.ClassID &&
+			mapping.SubjectID == req.SubjectID && mapping.Year == req.Year {
+			return errUniqueViolation
+		}
+	}
+	return nil
+}
+
+func (s *Store) FetchSCSMappings(ctx context.Context, schoolID string, year int) ([]models.SCSMapping, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mappings := []models.SCSMapping{}
+	for _, mapping := range s.mappings {
+		if (schoolID == "" || mapping.SchoolID == schoolID) && (year == 0 || mapping.Year == year) {
+			mappings = append(mappings, mapping)
+		}
+	}
+	return mappings, nil
+}
+
+func (s *Store) FetchSCSMappingByID(ctx context.Context, id string) (models.SCSMapping, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mapping, ok := s.mapping(id)
+	if !ok {
+		return models.SCSMapping{}, pgx.ErrNoRows
+	}
+	return mapping, nil
+}
+
+func (s *Store) CreateSCSMapping(ctx context.Context, req models.SCSMappingRequest) (models.SCSMapping, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkSCSMapping("", req); err != nil {
+		return models.SCSMapping{}, err
+	}
+	now := time.Now()
+	mapping := models.SCSMapping{
+		ID:        newID(),
+		SchoolID:  req.SchoolID,
+		ClassID:   req.ClassID,
+		SubjectID: req.SubjectID,
+		Year:      req.Year,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.mappings = append(s.mappings, mapping)
+	return mapping, nil
+}
+
+func (s *Store) UpdateSCSMapping(ctx context.Context, id string, req models.SCSMappingRequest) (models.SCSMapping, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.mappings {
+		if s.mappings[i].ID != id {
+			continue
+		}
+		if err := s.checkSCSMapping(id, req); err != nil {
+			return models.SCSMapping{}, err
+		}
+		s.mappings[i].SchoolID = req.SchoolID
+		s.mappings[i].ClassID = req.ClassID
+		s.mappings[i].SubjectID = req.SubjectID
+		s.mappings[i].Year = req.Year
+		s.mappings[i].UpdatedAt = time.Now()
+		return s.mappings[i], nil
+	}
+	return models.SCSMapping{}, pgx.ErrNoRows
+}
+
+func (s *Store) DeleteSCSMapping(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, enrollment := range s.enrollments {
+		if enrollment.ScsID == id {
+			return errForeignKeyViolation
+		}
+	}
+	for _, chat := range s.chats {
+		if chat.ScsID != nil && *chat.ScsID == id {
+			return errForeignKeyViolation
+		}
+	}
+	for i, mapping := range s.mappings {
+		if mapping.ID == id {
+			s.mappings = append(s.mappings[:i], s.mappings[i+1:]...)
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+// ------------------
+// Enrollments
+// ------------------
+
+func (s *Store) FetchEnrollments(ctx context.Context, scsID string) ([]models.StudentSCSMapping, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	enrollments := []models.StudentSCSMapping{}
+	for _, enrollment := range s.enrollments {
+		if enrollment.ScsID == scsID {
+			enrollments = append(enrollments, enrollment)
+		}
+	}
+	sort.SliceStable(enrollments, func(i, j int) bool { return enrollments[i].IsActive && !enrollments[j].IsActive })
+	return enrollments, nil
+}
+
+func (s *Store) EnrollStudent(ctx context.Context, scsID string, studentID string) (models.StudentSCSMapping, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.students[studentID]; !ok {
+		return models.StudentSCSMapping{}, errForeignKeyViolation
+	}
+	if _, ok := s.mapping(scsID); !ok {
+		return models.StudentSCSMapping{}, errForeignKeyViolation
+	}
+	now := time.Now()
+	for i := range s.enrollments {
+		if s.enrollments[i].StudentID == studentID && s.enrollments[i].ScsID == scsID {
+			s.enrollments[i].IsActive = true
+			s.enrollments[i].UpdatedAt = now
+			return s.enrollments[i], nil
+		}
+	}
+	enrollment := models.StudentSCSMapping{ID: newID(), StudentID: studentID, ScsID: scsID, IsActive: true, CreatedAt: now, UpdatedAt: now}
+	s.enrollments = append(s.enrollments, enrollment)
+	return enrollment, nil
+}
+
+func (s *Store) UnenrollStudent(ctx context.Context, scsID string, studentID string) (models.StudentSCSMapping, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.enrollments {
+		if s.enrollments[i].StudentID == studentID && s.enrollments[i].ScsID == scsID {
+			s.enrollments[i].IsActive = false
+			s.enrollments[i].UpdatedAt = time.Now()
+			return s.enrollments[i], nil
+		}
+	}
+	return models.StudentSCSMapping{}, pgx.ErrNoRows
+}
+
+// ------------------
+// Rollovers
+// ------------------
+
+func (s *Store) FetchRolloverRuns(ctx context.Context, schoolID string) ([]models.RolloverRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs := []models.RolloverRun{}
+	for i := len(s.rollovers) - 1; i >= 0; i-- { // newest first
+		if schoolID == "" || s.rollovers[i].SchoolID == schoolID {
+			runs = append(runs, s.rollovers[i])
+		}
+	}
+	return runs, nil
+}
+
+// Rollovers is a fake of rollover.Rollover. Run clones the mappings of the source year but
+// promotes nobody; it keeps the run so Undo and the run list behave as they do in Postgres.
+type Rollovers struct {
+	Store *Store
+}
+
+func (r *Rollovers) Run(ctx context.Context, schoolID string, fromYear int, ladder []string, createdBy string, dryRun bool) (models.RolloverDiff, error) {
+	s := r.Store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(ladder) == 0 {
+		return models.RolloverDiff{}, rollover.ErrEmptyLadder
+	}
+	for _, nameOrID := range ladder {
+		if !slices.ContainsFunc(s.named[handlers.ClassesTable.Table], func(class models.NamedEntity) bool {
+			return class.ID == nameOrID || strings.EqualFold(class.Name, nameOrID)
+		}) {
+			return models.RolloverDiff{}, fmt.Errorf("%w: class %q not found", rollover.ErrInvalidLadder, nameOrID)
+		}
+	}
+	diff := models.RolloverDiff{
+		DryRun:         dryRun,
+		SchoolID:       schoolID,
+		FromYear:       fromYear,
+		ToYear:         fromYear + 1,
+		ClonedMappings: []models.RolloverMapping{},
+		Promotions:     []models.RolloverPromotion{},
+		Graduations:    []models.RolloverPromotion{},
+		Skipped:        []models.RolloverSkip{},
+	}
+	for _, mapping := range s.mappings {
+		if mapping.SchoolID == schoolID && mapping.Year == fromYear {
+			diff.ClonedMappings = append(diff.ClonedMappings, models.RolloverMapping{
+				ScsID:   mapping.ID,
+				Class:   s.name(handlers.ClassesTable.Table, mapping.ClassID),
+				Subject: s.name(handlers.SubjectsTable.Table, mapping.SubjectID),
+			})
+		}
+	}
+	if len(diff.ClonedMappings) == 0 {
+		return models.RolloverDiff{}, rollover.ErrNothingToRollOver
+	}
+	if dryRun {
+		return diff, nil
+	}
+
+	run := models.RolloverRun{ID: newID(), SchoolID: schoolID, FromYear: fromYear, ToYear: fromYear + 1, CreatedAt: time.Now()}
+	if createdBy != "" {
+		run.CreatedBy = &createdBy
+	}
+	s.rollovers = append(s.rollovers, run)
+	diff.RunID = run.ID
+	return diff, nil
+}
+
+func (r *Rollovers) Undo(ctx context.Context, runID string) (models.RolloverRun, error) {
+	s := r.Store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.rollovers {
+		run := &s.rollovers[i]
+		if run.ID != runID {
+			continue
+		}
+		if run.UndoneAt != nil {
+			return *run, rollover.ErrAlreadyUndone
+		}
+		for _, later := range s.rollovers[i+1:] {
+			if later.SchoolID == run.SchoolID && later.UndoneAt == nil {
+				return *run, rollover.ErrNotLatestRun
+			}
+		}
+		now := time.Now()
+		run.UndoneAt = &now
+		return *run, nil
+	}
+	return models.RolloverRun{}, pgx.ErrNoRows
+}
+
+// Importer is a fake of roster.Importer: a row is invalid when it lacks an id or email,
+// everything else is counted as imported without creating accounts.
+type Importer struct {
+	// Imports records every committed import.
+	Imports [][]roster.Row
+}
+
+func (im *Importer) Import(ctx context.Context, rows []roster.Row, dryRun bool) (roster.Report, error) {
+	report := roster.Report{DryRun: dryRun, Total: len(rows), Rows: make([]roster.RowResult, 0, len(rows))}
+	for _, row := range rows {
+		result := roster.RowResult{Line: row.Line, Role: row.Role, ID: row.ID, Email: row.Email, Errors: []string{}}
+		if strings.TrimSpace(row.ID) == "" {
+			result.Errors = append(result.Errors, "id is required")
+		}
+		if strings.TrimSpace(row.Email) == "" {
+			result.Errors = append(result.Errors, "email is required")
+		}
+		if len(result.Errors) > 0 {
+			report.Invalid++
+		} else {
+			report.Valid++
+			switch row.Role {
+			case roster.RoleStudent:
+				report.Students++
+				result.Enrollments = len(row.Subjects)
+				report.Enrollments += result.Enrollments
+			case roster.RoleTeacher:
+				report.Teachers++
+			}
+		}
+		report.Rows = append(report.Rows, result)
+	}
+	if report.Invalid > 0 {
+		if dryRun {
+			return report, nil
+		}
+		return report, roster.ErrInvalidRoster
+	}
+	if !dryRun {
+		im.Imports = append(im.Imports, rows)
+		report.Committed = true
+	}
+	return report, nil
+}
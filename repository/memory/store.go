@@ -0,0 +1,524 @@
+// Package memory implements the repositories in memory for tests. A Store behaves like a
+// small database: it enforces the same uniqueness and foreign keys the schema does and
+// reports them with the same pgx errors, so controllers take the same code paths.
+package memory
+
+import (
+	"backend/config"
+	"backend/models"
+	"backend/repository"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	_ repository.Accounts   = (*Store)(nil)
+	_ repository.Students   = (*Store)(nil)
+	_ repository.Chats      = (*Store)(nil)
+	_ repository.Messages   = (*Store)(nil)
+	_ repository.SCS        = (*Store)(nil)
+	_ repository.Moderation = (*Store)(nil)
+	_ repository.Admin      = (*Store)(nil)
+)
+
+var (
+	errUniqueViolation     = &pgconn.PgError{Code: "23505", Message: "duplicate key value violates unique constraint"}
+	errForeignKeyViolation = &pgconn.PgError{Code: "23503", Message: "violates foreign key constraint"}
+)
+
+type account struct {
+	models.UserAccount
+	password string
+	school   string // teachers only: the school id
+}
+
+type Store struct {
+	mu          sync.Mutex
+	accounts    []*account
+	students    map[string]models.Student
+	chats       []models.PublicChat
+	messages    []models.PublicChatMessage
+	named       map[string][]models.NamedEntity // by table
+	mappings    []models.SCSMapping
+	enrollments []models.StudentSCSMapping
+	blocklist   []models.BlocklistTerm
+	rollovers   []models.RolloverRun
+}
+
+func NewStore() *Store {
+	return &Store{
+		students: map[string]models.Student{},
+		named:    map[string][]models.NamedEntity{},
+	}
+}
+
+// NewSet returns a repository.Set whose repositories all share the store.
+func NewSet(store *Store) repository.Set {
+	return repository.Set{
+		Accounts:   store,
+		Students:   store,
+		Chats:      store,
+		Messages:   store,
+		SCS:        store,
+		Moderation: store,
+		Admin:      store,
+		Roster:     &Importer{},
+		Rollovers:  &Rollovers{Store: store},
+	}
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b)
+	return fmt.Sprintf("%s-%s-%s-%s-%s", h[0:8], h[8:12], h[12:16], h[16:20], h[20:32])
+}
+
+// ------------------
+// Accounts and students
+// ------------------
+
+// CreateUser adds an account like handlers.UserHandler.CreateUser; students also get a profile.
+func (s *Store) CreateUser(ctx context.Context, req models.CreateUserRequest) (models.UserAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.accounts {
+		if a.Role == req.Role && a.Email == req.Email {
+			return models.UserAccount{}, errUniqueViolation
+		}
+	}
+	a := &account{
+		UserAccount: models.UserAccount{ID: newID(), Role: req.Role, FullName: req.FullName, Email: req.Email},
+		password:    req.Password,
+		school:      req.School,
+	}
+	if req.Role != config.RoleAdmin {
+		externalID := req.ExternalID
+		a.ExternalID = &externalID
+	}
+	s.accounts = append(s.accounts, a)
+
+	if req.Role == config.RoleStudent {
+		s.students[a.ID] = models.Student{ID: a.ID, StudentID: req.ExternalID, FullName: req.FullName, Email: req.Email, Phone: req.Phone}
+	}
+	return a.UserAccount, nil
+}
+
+// DisableUser blocks future logins of the account.
+func (s *Store) DisableUser(ctx context.Context, role string, email string) (models.UserAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.accounts {
+		if a.Role == role && a.Email == email {
+			if a.DisabledAt == nil {
+				now := time.Now()
+				a.DisabledAt = &now
+			}
+			return a.UserAccount, nil
+		}
+	}
+	return models.UserAccount{}, pgx.ErrNoRows
+}
+
+func (s *Store) FetchUserByID(ctx context.Context, role string, id string) (models.UserAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.accounts {
+		if a.Role == role && a.ID == id {
+			return a.UserAccount, nil
+		}
+	}
+	return models.UserAccount{}, pgx.ErrNoRows
+}
+
+func (s *Store) Authenticate(ctx context.Context, role string, email string, password string) (models.UserAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.accounts {
+		if a.Role == role && a.Email == email && a.password == password && a.DisabledAt == nil {
+			return a.UserAccount, nil
+		}
+	}
+	return models.UserAccount{}, pgx.ErrNoRows
+}
+
+func (s *Store) SetPassword(ctx context.Context, role string, id string, password string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.accounts {
+		if a.Role == role && a.ID == id {
+			a.password = password
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+func (s *Store) FetchStudentByID(ctx context.Context, id string) (models.Student, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	student, ok := s.students[id]
+	if !ok {
+		return models.Student{}, pgx.ErrNoRows
+	}
+	return student, nil
+}
+
+// ------------------
+// Chats and messages
+// ------------------
+
+// AddChat stores a chat, assigning its id.
+func (s *Store) AddChat(chat models.PublicChat) models.PublicChat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	chat.ID = newID()
+	chat.CreatedAt, chat.UpdatedAt = &now, &now
+	s.chats = append(s.chats, chat)
+	return chat
+}
+
+func (s *Store) FetchChatList(ctx context.Context, studentID string, page models.Page) ([]models.PublicChat, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chats := []models.PublicChat{}
+	for i := len(s.chats) - 1; i >= 0; i-- { // newest first
+		if chat := s.chats[i]; chat.StudentID != nil && *chat.StudentID == studentID {
+			chats = append(chats, chat)
+		}
+	}
+	return paginate(chats, page), nil
+}
+
+func (s *Store) FetchChatDetailsByID(ctx context.Context, studentID string, chatID string) (models.PublicChat, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, chat := range s.chats {
+		if chat.ID == chatID && chat.StudentID != nil && *chat.StudentID == studentID {
+			return chat, nil
+		}
+	}
+	return models.PublicChat{}, pgx.ErrNoRows
+}
+
+func (s *Store) chat(id string) (models.PublicChat, bool) {
+	for _, chat := range s.chats {
+		if chat.ID == id {
+			return chat, true
+		}
+	}
+	return models.PublicChat{}, false
+}
+
+func (s *Store) FetchChatMessages(ctx context.Context, chatID string, page models.Page) ([]models.PublicChatMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := []models.PublicChatMessage{}
+	for i := len(s.messages) - 1; i >= 0; i-- { // newest first
+		if s.messages[i].ChatID == chatID {
+			messages = append(messages, s.messages[i])
+		}
+	}
+	return paginate(messages, page), nil
+}
+
+// paginate applies page the way LIMIT and OFFSET do.
+func paginate[T any](items []T, page models.Page) []T {
+	items = items[min(page.Offset, len(items)):]
+	if page.Limit > 0 && page.Limit < len(items) {
+		items = items[:page.Limit]
+	}
+	return items
+}
+
+func (s *Store) FetchAnsweredMessageInSCS(ctx context.Context, scsID string, messageID string) (models.PublicChatMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, message := range s.messages {
+		if message.ID != messageID || message.Answer == nil {
+			continue
+		}
+		if chat, ok := s.chat(message.ChatID); ok && chat.ScsID != nil && *chat.ScsID == scsID {
+			return message, nil
+		}
+	}
+	return models.PublicChatMessage{}, pgx.ErrNoRows
+}
+
+func (s *Store) CreateChatMessage(ctx context.Context, chatID string, question string, answer *string, verdict models.ModerationVerdict) (models.PublicChatMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.chat(chatID); !ok {
+		return models.PublicChatMessage{}, errForeignKeyViolation
+	}
+	now := time.Now()
+	message := models.PublicChatMessage{
+		ID:                newID(),
+		ChatID:            chatID,
+		Question:          question,
+		Answer:            answer,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		ModerationStatus:  verdict.Status,
+		ModerationReasons: verdict.Reasons,
+	}
+	if answer != nil {
+		message.AnsweredAt = &now
+	}
+	s.messages = append(s.messages, message)
+	return message, nil
+}
+
+// SaveMessageAnswer lets the store back an answer.Worker.
+func (s *Store) SaveMessageAnswer(ctx context.Context, messageID string, answer string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.messages {
+		if s.messages[i].ID == messageID {
+			now := time.Now()
+			s.messages[i].Answer = &answer
+			s.messages[i].AnsweredAt = &now
+			s.messages[i].UpdatedAt = now
+			return nil
+		}
+	}
+	return nil
+}
+
+// FetchSimilarQuestions lets the store back a similarity.Detector. Instead of trigram
+// similarity it only matches questions that are equal ignoring case, with a score of 1.
+func (s *Store) FetchSimilarQuestions(ctx context.Context, scsID string, question string, minScore float64, limit int) ([]models.SimilarQuestion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matches := []models.SimilarQuestion{}
+	for _, message := range s.messages {
+		if len(matches) == limit {
+			break
+		}
+		if message.Answer == nil || message.ModerationStatus != models.ModerationApproved || !strings.EqualFold(message.Question, question) {
+			continue
+		}
+		if chat, ok := s.chat(message.ChatID); ok && chat.ScsID != nil && *chat.ScsID == scsID {
+			matches = append(matches, models.SimilarQuestion{
+				MessageID: message.ID,
+				ChatID:    message.ChatID,
+				Question:  message.Question,
+				Answer:    *message.Answer,
+				Score:     1,
+				Source:    "trigram",
+			})
+		}
+	}
+	return matches, nil
+}
+
+// ------------------
+// SCS
+// ------------------
+
+func (s *Store) FetchSCSDetailsByUserID(ctx context.Context, studentID string) ([]models.YearWiseDetails, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type group struct {
+		year     int
+		isActive bool
+	}
+	byGroup := map[group][]models.SCSDetail{}
+	for _, enrollment := range s.enrollments {
+		if enrollment.StudentID != studentID {
+			continue
+		}
+		mapping, ok := s.mapping(enrollment.ScsID)
+		if !ok {
+			continue
+		}
+		g := group{year: mapping.Year, isActive: enrollment.IsActive}
+		byGroup[g] = append(byGroup[g], models.SCSDetail{
+			ScsID:   mapping.ID,
+			School:  s.name("schools", mapping.SchoolID),
+			Class:   s.name("classes", mapping.ClassID),
+			Subject: s.name("subjects", mapping.SubjectID),
+		})
+	}
+
+	var results []models.YearWiseDetails
+	for g, details := range byGroup {
+		sort.Slice(details, func(i, j int) bool { return details[i].Subject < details[j].Subject })
+		results = append(results, models.YearWiseDetails{Year: g.year, IsActive: g.isActive, Details: details})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Year != results[j].Year {
+			return results[i].Year < results[j].Year
+		}
+		return !results[i].IsActive && results[j].IsActive
+	})
+	return results, nil
+}
+
+// ------------------
+// Moderation
+// ------------------
+
+func (s *Store) teacher(id string) (*account, bool) {
+	for _, a := range s.accounts {
+		if a.Role == config.RoleTeacher && a.ID == id {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+// inTeacherScope mirrors the teacherScope condition of handlers.ModerationHandler.
+func (s *Store) inTeacherScope(teacherID string, chat models.PublicChat) bool {
+	if chat.TeacherId != nil && *chat.TeacherId == teacherID {
+		return true
+	}
+	t, ok := s.teacher(teacherID)
+	if !ok || chat.ScsID == nil {
+		return false
+	}
+	mapping, ok := s.mapping(*chat.ScsID)
+	return ok && mapping.SchoolID == t.school
+}
+
+func (s *Store) FetchBlocklistTermsBySCS(ctx context.Context, scsID string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	terms := []string{}
+	if mapping, ok := s.mapping(scsID); ok {
+		for _, term := range s.blocklist {
+			if term.SchoolID == mapping.SchoolID {
+				terms = append(terms, term.Term)
+			}
+		}
+	}
+	return terms, nil
+}
+
+func (s *Store) flagged(message models.PublicChatMessage, chat models.PublicChat) models.FlaggedMessage {
+	return models.FlaggedMessage{PublicChatMessage: message, StudentID: chat.StudentID, ScsID: chat.ScsID}
+}
+
+func (s *Store) FetchFlaggedMessages(ctx context.Context, teacherID string) ([]models.FlaggedMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := []models.FlaggedMessage{}
+	for _, message := range s.messages {
+		if message.ModerationStatus != models.ModerationFlagged {
+			continue
+		}
+		if chat, ok := s.chat(message.ChatID); ok && s.inTeacherScope(teacherID, chat) {
+			messages = append(messages, s.flagged(message, chat))
+		}
+	}
+	return messages, nil
+}
+
+func (s *Store) ReviewMessage(ctx context.Context, teacherID string, messageID string, status string) (models.FlaggedMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, message := range s.messages {
+		if message.ID != messageID || message.ModerationStatus != models.ModerationFlagged {
+			continue
+		}
+		chat, ok := s.chat(message.ChatID)
+		if !ok || !s.inTeacherScope(teacherID, chat) {
+			break
+		}
+		now := time.Now()
+		reviewer := teacherID
+		s.messages[i].ModerationStatus = status
+		s.messages[i].ReviewedBy = &reviewer
+		s.messages[i].ReviewedAt = &now
+		s.messages[i].UpdatedAt = now
+		return s.flagged(s.messages[i], chat), nil
+	}
+	return models.FlaggedMessage{}, pgx.ErrNoRows
+}
+
+func (s *Store) FetchBlocklist(ctx context.Context, teacherID string) ([]models.BlocklistTerm, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	terms := []models.BlocklistTerm{}
+	if t, ok := s.teacher(teacherID); ok {
+		for _, term := range s.blocklist {
+			if term.SchoolID == t.school {
+				terms = append(terms, term)
+			}
+		}
+	}
+	sort.Slice(terms, func(i, j int) bool { return terms[i].Term < terms[j].Term })
+	return terms, nil
+}
+
+func (s *Store) AddBlocklistTerm(ctx context.Context, teacherID string, term string) (models.BlocklistTerm, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.teacher(teacherID)
+	if !ok {
+		return models.BlocklistTerm{}, pgx.ErrNoRows
+	}
+	if _, ok := s.namedByID("schools", t.school); !ok {
+		return models.BlocklistTerm{}, pgx.ErrNoRows
+	}
+	term = strings.ToLower(term)
+	for _, existing := range s.blocklist {
+		if existing.SchoolID == t.school && existing.Term == term {
+			return existing, nil
+		}
+	}
+	blocklistTerm := models.BlocklistTerm{ID: newID(), SchoolID: t.school, Term: term, CreatedBy: &t.ID, CreatedAt: time.Now()}
+	s.blocklist = append(s.blocklist, blocklistTerm)
+	return blocklistTerm, nil
+}
+
+func (s *Store) DeleteBlocklistTerm(ctx context.Context, teacherID string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.teacher(teacherID)
+	if !ok {
+		return pgx.ErrNoRows
+	}
+	for i, term := range s.blocklist {
+		if term.ID == id && term.SchoolID == t.school {
+			s.blocklist = append(s.blocklist[:i], s.blocklist[i+1:]...)
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"backend/handlers"
+	"backend/rollover"
+	"backend/roster"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	_ Accounts       = (*handlers.UserHandler)(nil)
+	_ Students       = (*handlers.StudentHandler)(nil)
+	_ Chats          = (*handlers.StudentHandler)(nil)
+	_ Messages       = (*handlers.StudentHandler)(nil)
+	_ SCS            = (*handlers.StudentHandler)(nil)
+	_ Moderation     = (*handlers.ModerationHandler)(nil)
+	_ Admin          = (*handlers.AdminHandler)(nil)
+	_ RosterImporter = (*roster.Importer)(nil)
+	_ Rollovers      = (*rollover.Rollover)(nil)
+)
+
+// Set bundles every repository the routes need.
+type Set struct {
+	Accounts   Accounts
+	Students   Students
+	Chats      Chats
+	Messages   Messages
+	SCS        SCS
+	Moderation Moderation
+	Admin      Admin
+	Roster     RosterImporter
+	Rollovers  Rollovers
+}
+
+// NewPostgres returns the repositories backed by the pool. replicas may be nil; otherwise
+// the student reads that tolerate replication lag go through it.
+func NewPostgres(db *pgxpool.Pool, replicas handlers.ReadRouter) Set {
+	studentHandler := &handlers.StudentHandler{DB: db, Replicas: replicas}
+	return Set{
+		Accounts:   &handlers.UserHandler{DB: db},
+		Students:   studentHandler,
+		Chats:      studentHandler,
+		Messages:   studentHandler,
+		SCS:        studentHandler,
+		Moderation: &handlers.ModerationHandler{DB: db},
+		Admin:      &handlers.AdminHandler{DB: db},
+		Roster:     &roster.Importer{DB: db},
+		Rollovers:  &rollover.Rollover{DB: db},
+	}
+}
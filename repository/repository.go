@@ -0,0 +1,80 @@
+// Package repository declares the data access the controllers depend on. The Postgres
+// implementations live in handlers; package memory provides in-memory fakes for tests.
+//
+// Lookups of a single row return pgx.ErrNoRows when nothing matches, whatever the implementation.
+package repository
+
+import (
+	"backend/handlers"
+	"backend/models"
+	"backend/roster"
+	"context"
+)
+
+// Accounts holds the credentials of students, teachers and admins; the role picks which.
+type Accounts interface {
+	FetchUserByID(ctx context.Context, role string, id string) (models.UserAccount, error)
+	Authenticate(ctx context.Context, role string, email string, password string) (models.UserAccount, error)
+	SetPassword(ctx context.Context, role string, id string, password string) error
+}
+
+type Students interface {
+	FetchStudentByID(ctx context.Context, id string) (models.Student, error)
+}
+
+type Chats interface {
+	FetchChatList(ctx context.Context, studentID string, page models.Page) ([]models.PublicChat, error)
+	FetchChatDetailsByID(ctx context.Context, studentID string, chatID string) (models.PublicChat, error)
+}
+
+type Messages interface {
+	FetchChatMessages(ctx context.Context, chatID string, page models.Page) ([]models.PublicChatMessage, error)
+	FetchAnsweredMessageInSCS(ctx context.Context, scsID string, messageID string) (models.PublicChatMessage, error)
+	CreateChatMessage(ctx context.Context, chatID string, question string, answer *string, verdict models.ModerationVerdict) (models.PublicChatMessage, error)
+}
+
+// SCS is the student's view of their school/class/subject mappings.
+type SCS interface {
+	FetchSCSDetailsByUserID(ctx context.Context, studentID string) ([]models.YearWiseDetails, error)
+}
+
+type Moderation interface {
+	FetchBlocklistTermsBySCS(ctx context.Context, scsID string) ([]string, error)
+	FetchFlaggedMessages(ctx context.Context, teacherID string) ([]models.FlaggedMessage, error)
+	ReviewMessage(ctx context.Context, teacherID string, messageID string, status string) (models.FlaggedMessage, error)
+	FetchBlocklist(ctx context.Context, teacherID string) ([]models.BlocklistTerm, error)
+	AddBlocklistTerm(ctx context.Context, teacherID string, term string) (models.BlocklistTerm, error)
+	DeleteBlocklistTerm(ctx context.Context, teacherID string, id string) error
+}
+
+// Admin manages schools, classes, subjects, SCS mappings and enrollments.
+type Admin interface {
+	FetchNamed(ctx context.Context, t handlers.NamedTable) ([]models.NamedEntity, error)
+	FetchNamedByID(ctx context.Context, t handlers.NamedTable, id string) (models.NamedEntity, error)
+	CreateNamed(ctx context.Context, t handlers.NamedTable, name string) (models.NamedEntity, error)
+	UpdateNamed(ctx context.Context, t handlers.NamedTable, id string, name string) (models.NamedEntity, error)
+	DeleteNamed(ctx context.Context, t handlers.NamedTable, id string) error
+
+	FetchSCSMappings(ctx context.Context, schoolID string, year int) ([]models.SCSMapping, error)
+	FetchSCSMappingByID(ctx context.Context, id string) (models.SCSMapping, error)
+	CreateSCSMapping(ctx context.Context, req models.SCSMappingRequest) (models.SCSMapping, error)
+	UpdateSCSMapping(ctx context.Context, id string, req models.SCSMappingRequest) (models.SCSMapping, error)
+	DeleteSCSMapping(ctx context.Context, id string) error
+
+	FetchEnrollments(ctx context.Context, scsID string) ([]models.StudentSCSMapping, error)
+	EnrollStudent(ctx context.Context, scsID string, studentID string) (models.StudentSCSMapping, error)
+	UnenrollStudent(ctx context.Context, scsID string, studentID string) (models.StudentSCSMapping, error)
+
+	FetchRolloverRuns(ctx context.Context, schoolID string) ([]models.RolloverRun, error)
+}
+
+// RosterImporter is implemented by roster.Importer.
+type RosterImporter interface {
+	Import(ctx context.Context, rows []roster.Row, dryRun bool) (roster.Report, error)
+}
+
+// Rollovers is implemented by rollover.Rollover.
+type Rollovers interface {
+	Run(ctx context.Context, schoolID string, fromYear int, ladder []string, createdBy string, dryRun bool) (models.RolloverDiff, error)
+	Undo(ctx context.Context, runID string) (models.RolloverRun, error)
+}
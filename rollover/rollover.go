@@ -0,0 +1,376 @@
+package rollover
+
+import (
+	"backend/handlers"
+	"backend/models"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrEmptyLadder       = errors.New("class ladder is empty")
+	ErrInvalidLadder     = errors.New("invalid class ladder")
+	ErrAlreadyUndone     = errors.New("rollover was already undone")
+	ErrNotLatestRun      = errors.New("only the latest rollover of a school can be undone")
+	ErrNotUndoable       = errors.New("rollover cannot be undone")
+	ErrNothingToRollOver = errors.New("school has no mappings in the source year")
+)
+
+// Undo log actions, replayed in reverse order by Undo.
+const (
+	actionCreateSCS            = "create_scs"
+	actionCreateEnrollment     = "create_enrollment"
+	actionActivateEnrollment   = "activate_enrollment"
+	actionDeactivateEnrollment = "deactivate_enrollment"
+)
+
+type Rollover struct {
+	DB *pgxpool.Pool
+}
+
+type scsRow struct {
+	id, classID, className, subjectID, subjectName string
+}
+
+type enrollmentRow struct {
+	id, studentID, scsID string
+}
+
+type action struct {
+	name, targetID string
+}
+
+// Run moves a school from fromYear to fromYear+1: mappings are cloned, students are promoted
+// along the ladder and their old enrollments deactivated. Every change is recorded in an undo
+// log. With dryRun the transaction is rolled back and only the diff is returned.
+func (r *Rollover) Run(ctx context.Context, schoolID string, fromYear int, ladder []string, createdBy string, dryRun bool) (models.RolloverDiff, error) {
+	diff := models.RolloverDiff{
+		DryRun:         dryRun,
+		SchoolID:       schoolID,
+		FromYear:       fromYear,
+		ToYear:         fromYear + 1,
+		ClonedMappings: []models.RolloverMapping{},
+		Promotions:     []models.RolloverPromotion{},
+		Graduations:    []models.RolloverPromotion{},
+		Skipped:        []models.RolloverSkip{},
+	}
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return diff, err
+	}
+	defer tx.Rollback(ctx)
+
+	next, err := resolveLadder(ctx, tx, ladder)
+	if err != nil {
+		return diff, err
+	}
+
+	source, err := fetchSCS(ctx, tx, schoolID, diff.FromYear)
+	if err != nil {
+		return diff, err
+	}
+	if len(source) == 0 {
+		return diff, ErrNothingToRollOver
+	}
+	target, err := fetchSCS(ctx, tx, schoolID, diff.ToYear)
+	if err != nil {
+		return diff, err
+	}
+
+	var actions []action
+
+	// clone every mapping of the source year that the target year does not have yet
+	targetByKey := map[string]scsRow{}
+	for _, row := range target {
+		targetByKey[row.classID+"/"+row.subjectID] = row
+	}
+	for _, row := range source {
+		key := row.classID + "/" + row.subjectID
+		if _, ok := targetByKey[key]; ok {
+			continue
+		}
+		var id string
+		err := tx.QueryRow(ctx, `
+			INSERT INTO school_class_subject_mapping (school_id, class_id, subject_id, year)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id
+		`, schoolID, row.classID, row.subjectID, diff.ToYear).Scan(&id)
+		if err != nil {
+			return diff, fmt.Errorf("clone mapping %s: %w", row.id, err)
+		}
+		clone := row
+		clone.id = id
+		targetByKey[key] = clone
+		actions = append(actions, action{actionCreateSCS, id})
+		diff.ClonedMappings = append(diff.ClonedMappings, models.RolloverMapping{ScsID: id, Class: row.className, Subject: row.subjectName})
+	}
+
+	classNames := map[string]string{}
+	for _, row := range source {
+		classNames[row.classID] = row.className
+	}
+	for _, row := range targetByKey {
+		classNames[row.classID] = row.className
+	}
+
+	sourceByID := map[string]scsRow{}
+	for _, row := range source {
+		sourceByID[row.id] = row
+	}
+
+	enrollments, err := fetchActiveEnrollments(ctx, tx, schoolID, diff.FromYear)
+	if err != nil {
+		return diff, err
+	}
+	for _, enrollment := range enrollments {
+		from := sourceByID[enrollment.scsID]
+		promotion := models.RolloverPromotion{
+			StudentID: enrollment.studentID,
+			FromScsID: from.id,
+			FromClass: from.className,
+			Subject:   from.subjectName,
+		}
+
+		nextClassID, inLadder := next[from.classID]
+		if !inLadder {
+			diff.Skipped = append(diff.Skipped, models.RolloverSkip{
+				StudentID: enrollment.studentID,
+				ScsID:     enrollment.scsID,
+				Reason:    fmt.Sprintf("class %s is not in the ladder", from.className),
+			})
+			continue
+		}
+
+		if nextClassID != "" {
+			to, ok := targetByKey[nextClassID+"/"+from.subjectID]
+			if !ok {
+				diff.Skipped = append(diff.Skipped, models.RolloverSkip{
+					StudentID: enrollment.studentID,
+					ScsID:     enrollment.scsID,
+					Reason:    fmt.Sprintf("%s is not offered for the next class", from.subjectName),
+				})
+				continue
+			}
+
+			// wasActive is NULL for a new enrollment; one that was already active is left
+			// out of the undo log so Undo does not deactivate it
+			var id string
+			var wasActive *bool
+			err := tx.QueryRow(ctx, `
+				WITH previous AS (
+					SELECT is_active FROM student_scs_mapping WHERE student_id = $1 AND scs_id = $2
+				)
+				INSERT INTO student_scs_mapping (student_id, scs_id, is_active)
+				VALUES ($1, $2, true)
+				ON CONFLICT (student_id, scs_id) DO UPDATE SET is_active = true, updated_at = now()
+				RETURNING id, (SELECT is_active FROM previous)
+			`, enrollment.studentID, to.id).Scan(&id, &wasActive)
+			if err != nil {
+				return diff, fmt.Errorf("promote student %s: %w", enrollment.studentID, err)
+			}
+			switch {
+			case wasActive == nil:
+				actions = append(actions, action{actionCreateEnrollment, id})
+			case !*wasActive:
+				actions = append(actions, action{actionActivateEnrollment, id})
+			}
+
+			promotion.ToScsID = to.id
+			promotion.ToClass = classNames[nextClassID]
+			diff.Promotions = append(diff.Promotions, promotion)
+		} else {
+			diff.Graduations = append(diff.Graduations, promotion)
+		}
+
+		_, err := tx.Exec(ctx,
+			`UPDATE student_scs_mapping SET is_active = false, updated_at = now() WHERE id = $1`,
+			enrollment.id,
+		)
+		if err != nil {
+			return diff, fmt.Errorf("deactivate enrollment %s: %w", enrollment.id, err)
+		}
+		actions = append(actions, action{actionDeactivateEnrollment, enrollment.id})
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO rollover_runs (school_id, from_year, to_year, created_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		RETURNING id
+	`, schoolID, diff.FromYear, diff.ToYear, createdBy).Scan(&diff.RunID)
+	if err != nil {
+		return diff, err
+	}
+	for i, a := range actions {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO rollover_actions (run_id, position, action, target_id) VALUES ($1, $2, $3, $4)`,
+			diff.RunID, i, a.name, a.targetID,
+		)
+		if err != nil {
+			return diff, err
+		}
+	}
+
+	if dryRun {
+		diff.RunID = ""
+		return diff, nil
+	}
+	return diff, tx.Commit(ctx)
+}
+
+// Undo reverts a rollover by replaying its undo log backwards. Only the most recent
+// run of a school may be undone so later runs never depend on reverted rows.
+func (r *Rollover) Undo(ctx context.Context, runID string) (models.RolloverRun, error) {
+	var run models.RolloverRun
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return run, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		SELECT id, school_id, from_year, to_year, created_by, created_at, undone_at
+		FROM rollover_runs WHERE id = $1 FOR UPDATE
+	`, runID).Scan(&run.ID, &run.SchoolID, &run.FromYear, &run.ToYear, &run.CreatedBy, &run.CreatedAt, &run.UndoneAt)
+	if err != nil {
+		return run, err
+	}
+	if run.UndoneAt != nil {
+		return run, ErrAlreadyUndone
+	}
+
+	var newer bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM rollover_runs
+			WHERE school_id = $1 AND undone_at IS NULL AND created_at > $2
+		)
+	`, run.SchoolID, run.CreatedAt).Scan(&newer)
+	if err != nil {
+		return run, err
+	}
+	if newer {
+		return run, ErrNotLatestRun
+	}
+
+	rows, err := tx.Query(ctx, `SELECT action, target_id FROM rollover_actions WHERE run_id = $1 ORDER BY position DESC`, runID)
+	if err != nil {
+		return run, err
+	}
+	actions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (action, error) {
+		var a action
+		err := row.Scan(&a.name, &a.targetID)
+		return a, err
+	})
+	if err != nil {
+		return run, err
+	}
+
+	for _, a := range actions {
+		var query string
+		switch a.name {
+		case actionCreateSCS:
+			query = `DELETE FROM school_class_subject_mapping WHERE id = $1`
+		case actionCreateEnrollment:
+			query = `DELETE FROM student_scs_mapping WHERE id = $1`
+		case actionActivateEnrollment:
+			query = `UPDATE student_scs_mapping SET is_active = false, updated_at = now() WHERE id = $1`
+		case actionDeactivateEnrollment:
+			query = `UPDATE student_scs_mapping SET is_active = true, updated_at = now() WHERE id = $1`
+		default:
+			return run, fmt.Errorf("unknown rollover action %q", a.name)
+		}
+		_, err := tx.Exec(ctx, query, a.targetID)
+		if handlers.IsForeignKeyViolation(err) {
+			// e.g. a student opened a chat in a mapping the rollover created
+			return run, fmt.Errorf("%w: the rollover's new data is already in use", ErrNotUndoable)
+		}
+		if err != nil {
+			return run, fmt.Errorf("undo %s %s: %w", a.name, a.targetID, err)
+		}
+	}
+
+	err = tx.QueryRow(ctx, `UPDATE rollover_runs SET undone_at = now() WHERE id = $1 RETURNING undone_at`, runID).Scan(&run.UndoneAt)
+	if err != nil {
+		return run, err
+	}
+	return run, tx.Commit(ctx)
+}
+
+// resolveLadder maps each class id to the id of the next class; the last class maps to "".
+func resolveLadder(ctx context.Context, tx pgx.Tx, ladder []string) (map[string]string, error) {
+	if len(ladder) == 0 {
+		return nil, ErrEmptyLadder
+	}
+
+	ids := make([]string, len(ladder))
+	for i, nameOrID := range ladder {
+		nameOrID = strings.TrimSpace(nameOrID)
+		err := tx.QueryRow(ctx,
+			`SELECT id FROM classes WHERE id::text = $1 OR lower(name) = lower($1) LIMIT 1`,
+			nameOrID,
+		).Scan(&ids[i])
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: class %q not found", ErrInvalidLadder, nameOrID)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	next := make(map[string]string, len(ids))
+	for i, id := range ids {
+		if _, ok := next[id]; ok {
+			return nil, fmt.Errorf("%w: class %q is listed twice", ErrInvalidLadder, ladder[i])
+		}
+		if i+1 < len(ids) {
+			next[id] = ids[i+1]
+		} else {
+			next[id] = ""
+		}
+	}
+	return next, nil
+}
+
+func fetchSCS(ctx context.Context, tx pgx.Tx, schoolID string, year int) ([]scsRow, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT scs.id, scs.class_id, classes.name, scs.subject_id, subjects.name
+		FROM school_class_subject_mapping AS scs
+		JOIN classes ON classes.id = scs.class_id
+		JOIN subjects ON subjects.id = scs.subject_id
+		WHERE scs.school_id = $1 AND scs.year = $2
+		ORDER BY classes.name, subjects.name
+	`, schoolID, year)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (scsRow, error) {
+		var r scsRow
+		err := row.Scan(&r.id, &r.classID, &r.className, &r.subjectID, &r.subjectName)
+		return r, err
+	})
+}
+
+func fetchActiveEnrollments(ctx context.Context, tx pgx.Tx, schoolID string, year int) ([]enrollmentRow, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT s_scs.id, s_scs.student_id, s_scs.scs_id
+		FROM student_scs_mapping AS s_scs
+		JOIN school_class_subject_mapping AS scs ON scs.id = s_scs.scs_id
+		WHERE scs.school_id = $1 AND scs.year = $2 AND s_scs.is_active
+		ORDER BY s_scs.student_id, s_scs.scs_id
+	`, schoolID, year)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (enrollmentRow, error) {
+		var r enrollmentRow
+		err := row.Scan(&r.id, &r.studentID, &r.scsID)
+		return r, err
+	})
+}
@@ -0,0 +1,157 @@
+package rollover_test
+
+import (
+	"backend/pgtest"
+	"backend/rollover"
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestMain(m *testing.M) { os.Exit(pgtest.Main(m)) }
+
+// enrollments returns Kiran's enrollments by SCS id; a missing key means no row.
+func enrollments(t *testing.T, pool *pgxpool.Pool) map[string]bool {
+	t.Helper()
+	return enrollmentsOf(t, pool, pgtest.KiranID)
+}
+
+// enrollmentsOf returns whether each enrollment of studentID is active, by SCS id.
+func enrollmentsOf(t *testing.T, pool *pgxpool.Pool, studentID string) map[string]bool {
+	t.Helper()
+	rows, err := pool.Query(context.Background(), `SELECT scs_id, is_active FROM student_scs_mapping WHERE student_id = $1`, studentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	active := map[string]bool{}
+	for rows.Next() {
+		var scsID string
+		var isActive bool
+		if err := rows.Scan(&scsID, &isActive); err != nil {
+			t.Fatal(err)
+		}
+		active[scsID] = isActive
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return active
+}
+
+func TestRunThenUndo(t *testing.T) {
+	pool := pgtest.Require(t, pgtest.FixtureSchool)
+	r := &rollover.Rollover{DB: pool}
+	ctx := context.Background()
+	ladder := []string{"Class 8", "Class 9"}
+
+	// Kiran is in Class 8 in 2024 and was already enrolled in 2025 Mathematics by hand
+	_, err := pool.Exec(ctx, `
+		INSERT INTO student_scs_mapping (student_id, scs_id, is_active)
+		VALUES ($1, $2, true), ($1, $3, true), ($1, $4, true)
+	`, pgtest.KiranID, pgtest.Maths2024SCSID, pgtest.Science2024SCSID, pgtest.Maths2025SCSID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := enrollments(t, pool)
+
+	if _, err := r.Run(ctx, pgtest.DemoSchoolID, 2024, []string{"Class 8", "Class 42"}, pgtest.MeeraAdminID, false); !errors.Is(err, rollover.ErrInvalidLadder) {
+		t.Fatalf("unknown ladder class: got %v, want ErrInvalidLadder", err)
+	}
+
+	dry, err := r.Run(ctx, pgtest.DemoSchoolID, 2024, ladder, pgtest.MeeraAdminID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dry.RunID != "" || len(dry.Promotions) != 2 || len(dry.ClonedMappings) != 0 {
+		t.Fatalf("dry run: got %+v", dry)
+	}
+	if got := enrollments(t, pool); len(got) != len(before) || !got[pgtest.Maths2024SCSID] {
+		t.Fatalf("dry run wrote enrollments: got %v", got)
+	}
+
+	diff, err := r.Run(ctx, pgtest.DemoSchoolID, 2024, ladder, pgtest.MeeraAdminID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.RunID == "" || len(diff.Promotions) != 2 {
+		t.Fatalf("run: got %+v", diff)
+	}
+	after := enrollments(t, pool)
+	want := map[string]bool{
+		pgtest.Maths2024SCSID:   false,
+		pgtest.Science2024SCSID: false,
+		pgtest.Maths2025SCSID:   true,
+		pgtest.Science2025SCSID: true,
+	}
+	for scsID, active := range want {
+		if isActive, ok := after[scsID]; !ok || isActive != active {
+			t.Fatalf("after run: got %v, want %v", after, want)
+		}
+	}
+
+	run, err := r.Undo(ctx, diff.RunID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.UndoneAt == nil {
+		t.Fatalf("undo: got %+v, want undone_at set", run)
+	}
+	// the enrollment that was active before the run stays active
+	undone := enrollments(t, pool)
+	if len(undone) != len(before) {
+		t.Fatalf("after undo: got %v, want %v", undone, before)
+	}
+	for scsID, active := range before {
+		if undone[scsID] != active {
+			t.Fatalf("after undo: got %v, want %v", undone, before)
+		}
+	}
+
+	if _, err := r.Undo(ctx, diff.RunID); !errors.Is(err, rollover.ErrAlreadyUndone) {
+		t.Fatalf("second undo: got %v, want ErrAlreadyUndone", err)
+	}
+}
+
+func TestUndoRefusedOnceNewMappingsAreUsed(t *testing.T) {
+	pool := pgtest.Require(t, pgtest.FixtureSchool)
+	r := &rollover.Rollover{DB: pool}
+	ctx := context.Background()
+
+	// 2026 has no mappings yet, so the rollover clones the 2025 ones
+	diff, err := r.Run(ctx, pgtest.DemoSchoolID, 2025, []string{"Class 8", "Class 9"}, pgtest.MeeraAdminID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.ClonedMappings) != 2 {
+		t.Fatalf("run: got %+v, want two cloned mappings", diff)
+	}
+	var chatID string
+	err = pool.QueryRow(ctx,
+		`INSERT INTO public_chats (student_id, scs_id, title) VALUES ($1, $2, 'Algebra') RETURNING id`,
+		pgtest.AshaID, diff.ClonedMappings[0].ScsID,
+	).Scan(&chatID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Undo(ctx, diff.RunID); !errors.Is(err, rollover.ErrNotUndoable) {
+		t.Fatalf("undo with a chat in a new mapping: got %v, want ErrNotUndoable", err)
+	}
+	// nothing was reverted, so the run can be undone once the chat is gone
+	if active := enrollmentsOf(t, pool, pgtest.AshaID); active[pgtest.Maths2025SCSID] {
+		t.Fatalf("failed undo reactivated enrollments: %v", active)
+	}
+	if _, err := pool.Exec(ctx, `DELETE FROM public_chats WHERE id = $1`, chatID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Undo(ctx, diff.RunID); err != nil {
+		t.Fatalf("undo after removing the chat: %v", err)
+	}
+	if active := enrollmentsOf(t, pool, pgtest.AshaID); !active[pgtest.Maths2025SCSID] || !active[pgtest.Science2025SCSID] {
+		t.Fatalf("after undo: got %v, want the 2025 enrollments active", active)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"backend/config"
+	"backend/roster"
+	"context"
+	"errors"
+	"flag"
+	"os"
+)
+
+func importCommand(args []string) int {
+	if len(args) == 0 || args[0] != "roster" {
+		return usageError("import needs roster")
+	}
+	return importRoster(args[1:])
+}
+
+func importRoster(args []string) int {
+	flags := flag.NewFlagSet("import roster", flag.ContinueOnError)
+	path := flags.String("file", "", "path to a .csv or .xlsx roster")
+	format := flags.String("format", "", "csv or xlsx, detected from the file name when empty")
+	commit := flags.Bool("commit", false, "write the roster; without it the import is a dry run")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *path == "" {
+		return usageError("--file is required")
+	}
+
+	if *format == "" {
+		detected, err := roster.FormatFromFilename(*path)
+		if err != nil {
+			return usageError("%s", err)
+		}
+		*format = detected
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		return printError(err)
+	}
+	defer file.Close()
+
+	rows, err := roster.Parse(file, *format)
+	if err != nil {
+		return printError(err)
+	}
+
+	pool, err := config.Connect(context.Background())
+	if err != nil {
+		return printError(err)
+	}
+	defer pool.Close()
+
+	importer := roster.Importer{DB: pool}
+	report, err := importer.Import(context.Background(), rows, !*commit)
+	printJSON(report)
+	if err != nil && !errors.Is(err, roster.ErrInvalidRoster) {
+		return printError(err)
+	}
+	if err != nil || report.Invalid > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
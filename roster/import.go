@@ -0,0 +1,308 @@
+package roster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	RoleStudent = "student"
+	RoleTeacher = "teacher"
+)
+
+// ErrInvalidRoster is returned by Import, unless it is a dry run, when at least one row failed validation.
+var ErrInvalidRoster = errors.New("roster has invalid rows")
+
+type RowResult struct {
+	Line   int      `json:"line"`
+	Role   string   `json:"role"`
+	ID     string   `json:"id"`
+	Email  string   `json:"email"`
+	Errors []string `json:"errors"`
+	// Enrollments is the number of student_scs_mapping rows the row creates.
+	Enrollments int `json:"enrollments"`
+	// GeneratedPassword is set when the file had no password and the import committed.
+	GeneratedPassword string `json:"generated_password,omitempty"`
+}
+
+type Report struct {
+	DryRun      bool        `json:"dry_run"`
+	Committed   bool        `json:"committed"`
+	Total       int         `json:"total"`
+	Valid       int         `json:"valid"`
+	Invalid     int         `json:"invalid"`
+	Students    int         `json:"students"`
+	Teachers    int         `json:"teachers"`
+	Enrollments int         `json:"enrollments"`
+	Rows        []RowResult `json:"rows"`
+}
+
+type Importer struct {
+	DB *pgxpool.Pool
+}
+
+// Import validates every row and creates the students, teachers and enrollments in a single
+// transaction. Nothing is written if any row is invalid. A dry run performs the same inserts
+// and rolls them back, so constraint violations surface before the real import.
+func (im *Importer) Import(ctx context.Context, rows []Row, dryRun bool) (Report, error) {
+	report := Report{DryRun: dryRun, Total: len(rows), Rows: make([]RowResult, 0, len(rows))}
+
+	tx, err := im.DB.Begin(ctx)
+	if err != nil {
+		return report, err
+	}
+	defer tx.Rollback(ctx)
+
+	lookup := newLookup(tx)
+	plans := make([]rowPlan, len(rows))
+	seenIDs := map[string]int{}
+	seenEmails := map[string]int{}
+
+	for i, row := range rows {
+		result := RowResult{Line: row.Line, Role: row.Role, ID: row.ID, Email: row.Email, Errors: []string{}}
+		plan, errs, err := lookup.plan(ctx, row)
+		if err != nil {
+			return report, err
+		}
+		result.Errors = append(result.Errors, errs...)
+
+		idKey, emailKey := row.Role+":"+row.ID, row.Role+":"+row.Email
+		if line, ok := seenIDs[idKey]; ok && row.ID != "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("id is duplicated on line %d", line))
+		} else {
+			seenIDs[idKey] = row.Line
+		}
+		if line, ok := seenEmails[emailKey]; ok && row.Email != "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("email is duplicated on line %d", line))
+		} else {
+			seenEmails[emailKey] = row.Line
+		}
+
+		if len(result.Errors) == 0 {
+			report.Valid++
+			result.Enrollments = len(plan.scsIDs)
+		} else {
+			report.Invalid++
+		}
+		plans[i] = plan
+		report.Rows = append(report.Rows, result)
+	}
+
+	if report.Invalid > 0 {
+		if dryRun {
+			return report, nil
+		}
+		return report, ErrInvalidRoster
+	}
+
+	for i, row := range rows {
+		password := row.Password
+		if password == "" {
+			password = generatePassword()
+			if !dryRun {
+				report.Rows[i].GeneratedPassword = password
+			}
+		}
+
+		switch row.Role {
+		case RoleStudent:
+			var studentID string
+			err := tx.QueryRow(ctx, `
+				INSERT INTO students (student_id, full_name, email, phone_number, dob, password)
+				VALUES ($1, $2, $3, $4, NULLIF($5, '')::date, $6)
+				RETURNING id
+			`, row.ID, row.FullName, row.Email, row.Phone, row.DOB, password).Scan(&studentID)
+			if err != nil {
+				return report, fmt.Errorf("line %d: create student: %w", row.Line, err)
+			}
+			for _, scsID := range plans[i].scsIDs {
+				_, err := tx.Exec(ctx,
+					`INSERT INTO student_scs_mapping (student_id, scs_id, is_active) VALUES ($1, $2, true)`,
+					studentID, scsID,
+				)
+				if err != nil {
+					return report, fmt.Errorf("line %d: enroll student: %w", row.Line, err)
+				}
+			}
+			report.Students++
+			report.Enrollments += len(plans[i].scsIDs)
+		case RoleTeacher:
+			_, err := tx.Exec(ctx, `
+				INSERT INTO teachers (teacher_id, full_name, email, phone, school, dob, password)
+				VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::date, $7)
+			`, row.ID, row.FullName, row.Email, row.Phone, plans[i].schoolID, row.DOB, password)
+			if err != nil {
+				return report, fmt.Errorf("line %d: create teacher: %w", row.Line, err)
+			}
+			report.Teachers++
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return report, err
+	}
+	report.Committed = true
+	return report, nil
+}
+
+type rowPlan struct {
+	schoolID string
+	scsIDs   []string
+}
+
+// lookup resolves names to ids inside the import transaction, caching repeated names.
+type lookup struct {
+	tx    pgx.Tx
+	cache map[string]string
+}
+
+func newLookup(tx pgx.Tx) *lookup {
+	return &lookup{tx: tx, cache: map[string]string{}}
+}
+
+func (l *lookup) plan(ctx context.Context, row Row) (rowPlan, []string, error) {
+	var plan rowPlan
+	var errs []string
+
+	if row.Role != RoleStudent && row.Role != RoleTeacher {
+		errs = append(errs, "role must be student or teacher")
+	}
+	if row.ID == "" {
+		errs = append(errs, "id is required")
+	}
+	if row.FullName == "" {
+		errs = append(errs, "full_name is required")
+	}
+	if _, err := mail.ParseAddress(row.Email); err != nil || !strings.Contains(row.Email, "@") {
+		errs = append(errs, "email is invalid")
+	}
+	if row.DOB != "" {
+		if _, err := time.Parse(time.DateOnly, row.DOB); err != nil {
+			errs = append(errs, "dob must be YYYY-MM-DD")
+		}
+	}
+
+	table, idColumn := "students", "student_id"
+	if row.Role == RoleTeacher {
+		table, idColumn = "teachers", "teacher_id"
+	}
+	if row.ID != "" && (row.Role == RoleStudent || row.Role == RoleTeacher) {
+		var exists bool
+		err := l.tx.QueryRow(ctx,
+			fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE %s = $1 OR lower(email) = $2)`, table, idColumn),
+			row.ID, row.Email,
+		).Scan(&exists)
+		if err != nil {
+			return plan, nil, err
+		}
+		if exists {
+			errs = append(errs, fmt.Sprintf("a %s with this id or email already exists", row.Role))
+		}
+	}
+
+	schoolID, err := l.resolve(ctx, "schools", row.School)
+	if err != nil {
+		return plan, nil, err
+	}
+	if schoolID == "" {
+		errs = append(errs, fmt.Sprintf("school %q not found", row.School))
+		return plan, errs, nil
+	}
+	plan.schoolID = schoolID
+
+	if row.Role != RoleStudent {
+		return plan, errs, nil
+	}
+
+	// students are enrolled into the mapping of every listed subject for their class and year
+	switch {
+	case row.Year == 0:
+		errs = append(errs, "year is required for students")
+	case row.Year < 2000 || row.Year > 2100:
+		errs = append(errs, "year must be between 2000 and 2100")
+	}
+	if row.Class == "" {
+		errs = append(errs, "class is required for students")
+	}
+	if len(row.Subjects) == 0 {
+		errs = append(errs, "subject is required for students")
+	}
+	if len(errs) > 0 {
+		return plan, errs, nil
+	}
+
+	classID, err := l.resolve(ctx, "classes", row.Class)
+	if err != nil {
+		return plan, nil, err
+	}
+	if classID == "" {
+		errs = append(errs, fmt.Sprintf("class %q not found", row.Class))
+		return plan, errs, nil
+	}
+
+	for _, subject := range row.Subjects {
+		subjectID, err := l.resolve(ctx, "subjects", subject)
+		if err != nil {
+			return plan, nil, err
+		}
+		if subjectID == "" {
+			errs = append(errs, fmt.Sprintf("subject %q not found", subject))
+			continue
+		}
+
+		var scsID string
+		err = l.tx.QueryRow(ctx, `
+			SELECT id FROM school_class_subject_mapping
+			WHERE school_id = $1 AND class_id = $2 AND subject_id = $3 AND year = $4
+		`, schoolID, classID, subjectID, row.Year).Scan(&scsID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			errs = append(errs, fmt.Sprintf("%s is not offered for %s in %d at this school", subject, row.Class, row.Year))
+			continue
+		}
+		if err != nil {
+			return plan, nil, err
+		}
+		plan.scsIDs = append(plan.scsIDs, scsID)
+	}
+	return plan, errs, nil
+}
+
+// resolve finds a school, class or subject by id or case-insensitive name; "" means not found.
+func (l *lookup) resolve(ctx context.Context, table string, nameOrID string) (string, error) {
+	if nameOrID == "" {
+		return "", nil
+	}
+	key := table + ":" + strings.ToLower(nameOrID)
+	if id, ok := l.cache[key]; ok {
+		return id, nil
+	}
+
+	var id string
+	err := l.tx.QueryRow(ctx,
+		fmt.Sprintf(`SELECT id FROM %s WHERE id::text = $1 OR lower(name) = lower($1) LIMIT 1`, table),
+		nameOrID,
+	).Scan(&id)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", err
+	}
+	l.cache[key] = id
+	return id, nil
+}
+
+func generatePassword() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
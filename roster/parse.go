@@ -0,0 +1,132 @@
+package roster
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Row is one person in a roster file. Line is the 1-based line (or sheet row) it came from.
+type Row struct {
+	Line     int      `json:"line"`
+	Role     string   `json:"role"`
+	ID       string   `json:"id"`
+	FullName string   `json:"full_name"`
+	Email    string   `json:"email"`
+	Phone    string   `json:"phone_number"`
+	DOB      string   `json:"dob"`
+	Password string   `json:"-"`
+	School   string   `json:"school"`
+	Class    string   `json:"class"`
+	Subjects []string `json:"subjects"`
+	Year     int      `json:"year"`
+}
+
+var requiredColumns = []string{"role", "id", "full_name", "email", "school"}
+
+// FormatFromFilename picks the roster format from a file extension.
+func FormatFromFilename(name string) (string, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".csv"):
+		return FormatCSV, nil
+	case strings.HasSuffix(lower, ".xlsx"):
+		return FormatXLSX, nil
+	}
+	return "", fmt.Errorf("unsupported roster file %q, expected .csv or .xlsx", name)
+}
+
+// Parse reads a roster with a header row. Columns are matched by name, so their order is free.
+// Subjects may be separated by ";" to enroll a student in several subjects at once.
+func Parse(r io.Reader, format string) ([]Row, error) {
+	var records [][]string
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		all, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("read csv: %w", err)
+		}
+		records = all
+	case FormatXLSX:
+		file, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("read xlsx: %w", err)
+		}
+		defer file.Close()
+		all, err := file.GetRows(file.GetSheetName(0))
+		if err != nil {
+			return nil, fmt.Errorf("read xlsx: %w", err)
+		}
+		records = all
+	default:
+		return nil, fmt.Errorf("unsupported roster format %q", format)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("roster is empty")
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("roster is missing the %q column", name)
+		}
+	}
+
+	rows := make([]Row, 0, len(records)-1)
+	for i, record := range records[1:] {
+		get := func(name string) string {
+			idx, ok := columns[name]
+			if !ok || idx >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[idx])
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		row := Row{
+			Line:     i + 2,
+			Role:     strings.ToLower(get("role")),
+			ID:       get("id"),
+			FullName: get("full_name"),
+			Email:    strings.ToLower(get("email")),
+			Phone:    get("phone_number"),
+			DOB:      get("dob"),
+			Password: get("password"),
+			School:   get("school"),
+			Class:    get("class"),
+		}
+		for _, subject := range strings.Split(get("subject"), ";") {
+			if subject = strings.TrimSpace(subject); subject != "" {
+				row.Subjects = append(row.Subjects, subject)
+			}
+		}
+		if year := get("year"); year != "" {
+			// a non-numeric year becomes -1 so validation can tell it apart from a missing one
+			parsed, err := strconv.Atoi(year)
+			if err != nil {
+				parsed = -1
+			}
+			row.Year = parsed
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
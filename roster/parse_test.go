@@ -0,0 +1,99 @@
+package roster_test
+
+import (
+	"backend/roster"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestParseCSV(t *testing.T) {
+	csv := "Email, Role ,id,full_name,school,class,subject,year,phone_number,password\n" +
+		"ASHA@Demo.local,Student,S-1,Asha,Demo School,Class 8,Maths; Science ;,2025,98765 43210,secret\n" +
+		",,,,,,,,,\n" +
+		" , ,  ,,,,,,,\n" +
+		"ravi@demo.local,teacher,T-1,Ravi,Demo School\n" +
+		"asha@demo.local,student,S-1,Asha again,Demo School,Class 8,,next year\n"
+
+	rows, err := roster.Parse(strings.NewReader(csv), roster.FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []roster.Row{
+		{Line: 2, Role: "student", ID: "S-1", FullName: "Asha", Email: "asha@demo.local", Phone: "98765 43210", Password: "secret", School: "Demo School", Class: "Class 8", Subjects: []string{"Maths", "Science"}, Year: 2025},
+		// blank lines are skipped but still counted
+		{Line: 5, Role: "teacher", ID: "T-1", FullName: "Ravi", Email: "ravi@demo.local", School: "Demo School"},
+		// duplicates are kept for Import to report; a non-numeric year becomes -1
+		{Line: 6, Role: "student", ID: "S-1", FullName: "Asha again", Email: "asha@demo.local", School: "Demo School", Class: "Class 8", Year: -1},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("got  %+v\nwant %+v", rows, want)
+	}
+}
+
+func TestParseXLSX(t *testing.T) {
+	file := excelize.NewFile()
+	sheet := file.GetSheetName(0)
+	for i, record := range [][]any{
+		{"school", "full_name", "id", "role", "email", "year"},
+		{"Demo School", "Asha", "S-1", "student", "asha@demo.local", 2025},
+		{},
+		{"Demo School", "Ravi", "T-1", "TEACHER", "ravi@demo.local"},
+	} {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := file.SetSheetRow(sheet, cell, &record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := file.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := roster.Parse(&buf, roster.FormatXLSX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []roster.Row{
+		{Line: 2, Role: "student", ID: "S-1", FullName: "Asha", Email: "asha@demo.local", School: "Demo School", Year: 2025},
+		{Line: 4, Role: "teacher", ID: "T-1", FullName: "Ravi", Email: "ravi@demo.local", School: "Demo School"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("got  %+v\nwant %+v", rows, want)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		format  string
+		want    string
+	}{
+		{"empty file", "", roster.FormatCSV, "empty"},
+		{"missing column", "role,id,full_name,email\nstudent,S-1,Asha,a@b.co\n", roster.FormatCSV, `"school"`},
+		{"broken quoting", "role,id,full_name,email,school\nstudent,\"S-1,Asha,a@b.co,Demo\n", roster.FormatCSV, "read csv"},
+		{"not a workbook", "role,id\n", roster.FormatXLSX, "read xlsx"},
+		{"unknown format", "", "ods", "unsupported"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := roster.Parse(strings.NewReader(tc.content), tc.format)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("got %v, want an error mentioning %s", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	for name, want := range map[string]string{"class8.CSV": roster.FormatCSV, "2025/roster.xlsx": roster.FormatXLSX, "roster.xls": ""} {
+		got, err := roster.FormatFromFilename(name)
+		if got != want || (want == "") != (err != nil) {
+			t.Errorf("%s: got %q, %v", name, got, err)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package routes_test
+
+import (
+	"backend/cache"
+	"backend/config"
+	"backend/models"
+	"backend/roster"
+	"backend/routes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNamedEntityConflicts(t *testing.T) {
+	f := newFixture(t)
+	cases := []struct {
+		name   string
+		method string
+		url    string
+		body   any
+		want   int
+	}{
+		{"duplicate name", http.MethodPost, "/v1/admin/schools", models.NamedEntityRequest{Name: f.school.Name}, http.StatusConflict},
+		{"rename onto existing", http.MethodPut, "/v1/admin/subjects/" + f.spare["subjects"].ID, models.NamedEntityRequest{Name: f.subject.Name}, http.StatusConflict},
+		{"delete in use", http.MethodDelete, "/v1/admin/classes/" + f.class.ID, nil, http.StatusConflict},
+		{"blank name", http.MethodPost, "/v1/admin/classes", models.NamedEntityRequest{Name: ""}, http.StatusBadRequest},
+		{"unknown id", http.MethodGet, "/v1/admin/schools/00000000-0000-4000-8000-000000000000", nil, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if rec := f.do(tc.method, tc.url, config.RoleAdmin, tc.body); rec.Code != tc.want {
+				t.Fatalf("got %d, want %d: %s", rec.Code, tc.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestSCSMappingValidation(t *testing.T) {
+	f := newFixture(t)
+	request := func(mutate func(*models.SCSMappingRequest)) models.SCSMappingRequest {
+		req := models.SCSMappingRequest{SchoolID: f.school.ID, ClassID: f.class.ID, SubjectID: f.subject.ID, Year: 2025}
+		mutate(&req)
+		return req
+	}
+	cases := []struct {
+		name   string
+		method string
+		url    string
+		body   any
+		want   int
+	}{
+		{"duplicate mapping", http.MethodPost, "/v1/admin/scs", request(func(*models.SCSMappingRequest) {}), http.StatusConflict},
+		{"update onto existing", http.MethodPut, "/v1/admin/scs/" + f.spareSCS.ID, request(func(*models.SCSMappingRequest) {}), http.StatusConflict},
+		{"unknown subject", http.MethodPost, "/v1/admin/scs", request(func(r *models.SCSMappingRequest) { r.SubjectID = f.chat.ID }), http.StatusBadRequest},
+		{"missing class", http.MethodPost, "/v1/admin/scs", request(func(r *models.SCSMappingRequest) { r.ClassID = "" }), http.StatusBadRequest},
+		{"year out of range", http.MethodPost, "/v1/admin/scs", request(func(r *models.SCSMappingRequest) { r.Year = 1999 }), http.StatusBadRequest},
+		{"delete with students", http.MethodDelete, "/v1/admin/scs/" + f.scs.ID, nil, http.StatusConflict},
+		{"bad year filter", http.MethodGet, "/v1/admin/scs?year=next", nil, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if rec := f.do(tc.method, tc.url, config.RoleAdmin, tc.body); rec.Code != tc.want {
+				t.Fatalf("got %d, want %d: %s", rec.Code, tc.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestEnrollmentKeepsInactiveRows(t *testing.T) {
+	f := newFixture(t)
+	url := "/v1/admin/scs/" + f.scs.ID + "/students"
+
+	unenrolled := decode[models.StudentSCSMapping](t, f.do(http.MethodDelete, url+"/"+f.student.ID, config.RoleAdmin, nil))
+	if unenrolled.IsActive {
+		t.Fatal("enrollment still active after unenroll")
+	}
+	enrollments := decode[[]models.StudentSCSMapping](t, f.do(http.MethodGet, url, config.RoleAdmin, nil))
+	if len(enrollments) != 1 || enrollments[0].IsActive {
+		t.Fatalf("got %+v, want one inactive enrollment", enrollments)
+	}
+
+	reenrolled := decode[models.StudentSCSMapping](t, f.do(http.MethodPost, url, config.RoleAdmin, models.EnrollmentRequest{StudentID: f.student.ID}))
+	if !reenrolled.IsActive || reenrolled.ID != unenrolled.ID {
+		t.Fatalf("got %+v, want the same enrollment reactivated", reenrolled)
+	}
+
+	if rec := f.do(http.MethodPost, url, config.RoleAdmin, models.EnrollmentRequest{StudentID: f.teacher.ID}); rec.Code != http.StatusNotFound {
+		t.Fatalf("enrolling a non-student: got %d, want 404", rec.Code)
+	}
+}
+
+func TestImportRoster(t *testing.T) {
+	const header = "role,id,full_name,email,school\n"
+	cases := []struct {
+		name  string
+		query string
+		file  upload
+		want  int
+	}{
+		{"dry run by default", "", upload{"roster.csv", header + "student,S-2,Kiran,kiran@demo.local,Demo School\n"}, http.StatusOK},
+		{"commit", "?dry_run=false", upload{"roster.csv", header + "teacher,T-2,Nila,nila@demo.local,Demo School\n"}, http.StatusOK},
+		{"invalid rows are not committed", "?dry_run=false", upload{"roster.csv", header + "student,,Kiran,kiran@demo.local,Demo School\n"}, http.StatusUnprocessableEntity},
+		{"unknown format", "", upload{"roster.txt", header}, http.StatusBadRequest},
+		{"missing columns", "", upload{"roster.csv", "role,id\nstudent,S-2\n"}, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t)
+			rec := f.do(http.MethodPost, "/v1/admin/import/roster"+tc.query, config.RoleAdmin, tc.file)
+			if rec.Code != tc.want {
+				t.Fatalf("got %d, want %d: %s", rec.Code, tc.want, rec.Body.String())
+			}
+			if tc.want != http.StatusOK {
+				return
+			}
+			report := decode[roster.Report](t, rec)
+			if report.Total != 1 || report.Valid != 1 || report.Committed == report.DryRun {
+				t.Fatalf("got %+v", report)
+			}
+		})
+	}
+}
+
+func TestRolloverAndUndo(t *testing.T) {
+	f := newFixture(t)
+	dryRun := false
+	rec := f.do(http.MethodPost, "/v1/admin/schools/"+f.school.ID+"/rollover", config.RoleAdmin,
+		models.RolloverRequest{FromYear: 2025, Ladder: []string{"Class 8", "Class 9"}, DryRun: &dryRun})
+	diff := decode[models.RolloverDiff](t, rec)
+	if diff.DryRun || diff.RunID == "" || diff.ToYear != 2026 {
+		t.Fatalf("got %+v", diff)
+	}
+
+	// the fixture's run is no longer the latest one
+	if rec := f.do(http.MethodPost, "/v1/admin/rollovers/"+f.rollover+"/undo", config.RoleAdmin, nil); rec.Code != http.StatusConflict {
+		t.Fatalf("undo older run: got %d, want 409", rec.Code)
+	}
+	if rec := f.do(http.MethodPost, "/v1/admin/rollovers/"+diff.RunID+"/undo", config.RoleAdmin, nil); rec.Code != http.StatusOK {
+		t.Fatalf("undo: got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := f.do(http.MethodPost, "/v1/admin/rollovers/"+diff.RunID+"/undo", config.RoleAdmin, nil); rec.Code != http.StatusConflict {
+		t.Fatalf("second undo: got %d, want 409", rec.Code)
+	}
+
+	runs := decode[[]models.RolloverRun](t, f.do(http.MethodGet, "/v1/admin/rollovers?school_id="+f.school.ID, config.RoleAdmin, nil))
+	if len(runs) != 2 || runs[0].ID != diff.RunID || runs[0].UndoneAt == nil {
+		t.Fatalf("got %+v", runs)
+	}
+
+	if rec := f.do(http.MethodPost, "/v1/admin/schools/"+f.school.ID+"/rollover", config.RoleAdmin, models.RolloverRequest{FromYear: 2030, Ladder: []string{"Class 8"}}); rec.Code != http.StatusNotFound {
+		t.Fatalf("rollover of an empty year: got %d, want 404", rec.Code)
+	}
+}
+
+// brokenRollovers fails like a lost database connection.
+type brokenRollovers struct{}
+
+func (brokenRollovers) Run(context.Context, string, int, []string, string, bool) (models.RolloverDiff, error) {
+	return models.RolloverDiff{}, errors.New(`read tcp 10.0.0.5:5432: connection reset; SELECT id FROM classes`)
+}
+
+func (brokenRollovers) Undo(context.Context, string) (models.RolloverRun, error) {
+	return models.RolloverRun{}, errors.New("connection reset")
+}
+
+func TestRolloverErrors(t *testing.T) {
+	f := newFixture(t)
+	url := "/v1/admin/schools/" + f.school.ID + "/rollover"
+	if rec := f.do(http.MethodPost, url, config.RoleAdmin, models.RolloverRequest{FromYear: 2025, Ladder: []string{"Class 8", "Class 42"}}); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Class 42") {
+		t.Fatalf("unknown ladder class: got %d %s, want 400", rec.Code, rec.Body.String())
+	}
+
+	f.repos.Rollovers = brokenRollovers{}
+	f.router = routes.SetupRoutes(f.repos, routes.Services{})
+	rec := f.do(http.MethodPost, url, config.RoleAdmin, models.RolloverRequest{FromYear: 2025, Ladder: []string{"Class 8"}})
+	if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "SELECT") || strings.Contains(rec.Body.String(), "10.0.0.5") {
+		t.Fatalf("database failure: got %d %s, want 500 without the cause", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCachedReadsFollowWrites(t *testing.T) {
+	f := newCachedFixture(t, cache.NewLRU(100))
+	ctx := context.Background()
+	scsDetails := func() []models.YearWiseDetails {
+		return decode[[]models.YearWiseDetails](t, f.do(http.MethodGet, "/v1/students/scs_mapping", config.RoleStudent, nil))
+	}
+	if d := scsDetails(); len(d) != 1 || d[0].Details[0].School != "Demo School" {
+		t.Fatalf("got %+v", d)
+	}
+
+	// writes that bypass the repositories stay invisible until the entry expires
+	if _, err := f.store.UpdateNamed(ctx, tables["schools"], f.school.ID, "Changed Behind The Cache"); err != nil {
+		t.Fatal(err)
+	}
+	if d := scsDetails(); d[0].Details[0].School != "Demo School" {
+		t.Fatalf("got %+v, want the cached name", d)
+	}
+
+	rec := f.do(http.MethodPut, "/v1/admin/schools/"+f.school.ID, config.RoleAdmin, models.NamedEntityRequest{Name: "Renamed School"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("rename: got %d: %s", rec.Code, rec.Body.String())
+	}
+	if d := scsDetails(); d[0].Details[0].School != "Renamed School" {
+		t.Fatalf("got %+v, want the new name after a rename", d)
+	}
+	if school := decode[models.NamedEntity](t, f.do(http.MethodGet, "/v1/admin/schools/"+f.school.ID, config.RoleAdmin, nil)); school.Name != "Renamed School" {
+		t.Fatalf("got %+v", school)
+	}
+
+	f.do(http.MethodDelete, "/v1/admin/scs/"+f.scs.ID+"/students/"+f.student.ID, config.RoleAdmin, nil)
+	if d := scsDetails(); d[0].IsActive {
+		t.Fatalf("got %+v, want the enrollment inactive after unenroll", d)
+	}
+
+	before := decode[[]models.NamedEntity](t, f.do(http.MethodGet, "/v1/admin/subjects", config.RoleAdmin, nil))
+	f.do(http.MethodPost, "/v1/admin/subjects", config.RoleAdmin, models.NamedEntityRequest{Name: "Geography"})
+	if after := decode[[]models.NamedEntity](t, f.do(http.MethodGet, "/v1/admin/subjects", config.RoleAdmin, nil)); len(after) != len(before)+1 {
+		t.Fatalf("got %d subjects after a create, want %d", len(after), len(before)+1)
+	}
+}
@@ -0,0 +1,130 @@
+package routes
+
+import (
+	"backend/config"
+	"backend/handlers"
+	"backend/models"
+	"backend/openapi"
+	"backend/roster"
+	"net/http"
+	"sync"
+)
+
+// pageParams are the query parameters of paginated lists (see controllers.pageQuery).
+var pageParams = []openapi.Parameter{
+	openapi.QueryParam("limit", "integer", "at most this many items, 1 to 100; all when omitted"),
+	openapi.QueryParam("offset", "integer", "skip this many items"),
+}
+
+// operations documents every /v1 route. TestEveryRouteIsDocumented fails when a route
+// is registered without an entry here.
+func operations() []openapi.Operation {
+	ops := []openapi.Operation{
+		{Method: http.MethodPost, Path: "/v1/public/students/login", Tag: "auth", Summary: "Log in as a student",
+			Request: models.StudentLoginRequest{}, Response: models.LoginResponse{}, Errors: []int{http.StatusUnauthorized}},
+		{Method: http.MethodPost, Path: "/v1/public/teacher/login", Tag: "auth", Summary: "Log in as a teacher",
+			Request: models.StudentLoginRequest{}, Response: models.LoginResponse{}, Errors: []int{http.StatusUnauthorized}},
+		{Method: http.MethodPost, Path: "/v1/public/admin/login", Tag: "auth", Summary: "Log in as an admin",
+			Request: models.StudentLoginRequest{}, Response: models.LoginResponse{}, Errors: []int{http.StatusUnauthorized}},
+		{Method: http.MethodPost, Path: "/v1/auth/refresh", Tag: "auth", Summary: "Exchange a valid token of any role for a fresh one",
+			Auth: true, Response: models.TokenResponse{}},
+
+		{Method: http.MethodGet, Path: "/v1/students/profile", Tag: "students", Summary: "Claims of the caller's token",
+			Auth: true, Response: models.TokenProfile{}},
+		{Method: http.MethodPost, Path: "/v1/students/reset-password", Tag: "students", Summary: "Change the caller's password",
+			Auth: true, Request: models.StudentResetPasswordRequest{}, Response: models.IDResponse{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodGet, Path: "/v1/students/me", Tag: "students", Summary: "The caller's student record",
+			Auth: true, Response: models.Student{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodGet, Path: "/v1/students/chats", Tag: "chats", Summary: "The caller's chats, newest first",
+			Auth: true, Response: []models.PublicChat{}, Query: pageParams, Errors: []int{http.StatusBadRequest}},
+		{Method: http.MethodGet, Path: "/v1/students/chats/:id", Tag: "chats", Summary: "One of the caller's chats",
+			Auth: true, Response: models.PublicChat{}},
+		{Method: http.MethodGet, Path: "/v1/students/chats/:id/messages", Tag: "chats", Summary: "Messages of a chat, newest first",
+			Auth: true, Response: []models.PublicChatMessage{}, Query: pageParams},
+		{Method: http.MethodPost, Path: "/v1/students/chats/:id/messages", Tag: "chats",
+			Summary: "Ask a question; answers 200 with duplicate matches instead when similar questions were already answered",
+			Auth:    true, Request: models.AskQuestionRequest{}, Response: models.AskQuestionResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/v1/students/scs_mapping", Tag: "students", Summary: "The caller's subjects grouped by year",
+			Auth: true, Response: []models.YearWiseDetails{}},
+
+		{Method: http.MethodGet, Path: "/v1/teachers/moderation/queue", Tag: "moderation", Summary: "Flagged questions the caller may review",
+			Auth: true, Response: []models.FlaggedMessage{}},
+		{Method: http.MethodPost, Path: "/v1/teachers/moderation/queue/:id", Tag: "moderation", Summary: "Approve or reject a flagged question",
+			Auth: true, Request: models.ModerationReviewRequest{}, Response: models.FlaggedMessage{}},
+		{Method: http.MethodGet, Path: "/v1/teachers/moderation/blocklist", Tag: "moderation", Summary: "Blocklisted terms of the caller's school",
+			Auth: true, Response: []models.BlocklistTerm{}},
+		{Method: http.MethodPost, Path: "/v1/teachers/moderation/blocklist", Tag: "moderation", Summary: "Blocklist a term for the caller's school",
+			Auth: true, Request: models.BlocklistTermRequest{}, Response: models.BlocklistTerm{}, Status: http.StatusCreated},
+		{Method: http.MethodDelete, Path: "/v1/teachers/moderation/blocklist/:id", Tag: "moderation", Summary: "Remove a blocklisted term",
+			Auth: true, Response: models.IDResponse{}},
+
+		{Method: http.MethodGet, Path: "/v1/admin/scs", Tag: "admin", Summary: "List school/class/subject mappings",
+			Auth: true, Response: []models.SCSMapping{}, Errors: []int{http.StatusBadRequest},
+			Query: []openapi.Parameter{
+				openapi.QueryParam("school_id", "string", "only mappings of this school"),
+				openapi.QueryParam("year", "integer", "only mappings of this academic year"),
+			}},
+		{Method: http.MethodPost, Path: "/v1/admin/scs", Tag: "admin", Summary: "Create a mapping",
+			Auth: true, Request: models.SCSMappingRequest{}, Response: models.SCSMapping{}, Status: http.StatusCreated, Errors: []int{http.StatusConflict}},
+		{Method: http.MethodGet, Path: "/v1/admin/scs/:id", Tag: "admin", Summary: "Get a mapping",
+			Auth: true, Response: models.SCSMapping{}},
+		{Method: http.MethodPut, Path: "/v1/admin/scs/:id", Tag: "admin", Summary: "Update a mapping",
+			Auth: true, Request: models.SCSMappingRequest{}, Response: models.SCSMapping{}, Errors: []int{http.StatusConflict}},
+		{Method: http.MethodDelete, Path: "/v1/admin/scs/:id", Tag: "admin", Summary: "Delete a mapping without students or chats",
+			Auth: true, Response: models.IDResponse{}, Errors: []int{http.StatusConflict}},
+		{Method: http.MethodGet, Path: "/v1/admin/scs/:id/students", Tag: "admin", Summary: "Enrollments of a mapping, active or not",
+			Auth: true, Response: []models.StudentSCSMapping{}},
+		{Method: http.MethodPost, Path: "/v1/admin/scs/:id/students", Tag: "admin", Summary: "Enroll a student, reactivating an earlier enrollment",
+			Auth: true, Request: models.EnrollmentRequest{}, Response: models.StudentSCSMapping{}},
+		{Method: http.MethodDelete, Path: "/v1/admin/scs/:id/students/:student_id", Tag: "admin", Summary: "Deactivate an enrollment",
+			Auth: true, Response: models.StudentSCSMapping{}},
+		{Method: http.MethodPost, Path: "/v1/admin/import/roster", Tag: "admin", Summary: "Validate and optionally import a CSV or XLSX roster",
+			Auth: true, Upload: true, Response: roster.Report{}, Errors: []int{http.StatusUnprocessableEntity},
+			Query: []openapi.Parameter{
+				openapi.QueryParam("dry_run", "boolean", "only validate; defaults to true"),
+				openapi.QueryParam("format", "string", "csv or xlsx; defaults to the file extension"),
+			}},
+		{Method: http.MethodPost, Path: "/v1/admin/schools/:id/rollover", Tag: "admin", Summary: "Promote a school to the next academic year",
+			Auth: true, Request: models.RolloverRequest{}, Response: models.RolloverDiff{}},
+		{Method: http.MethodGet, Path: "/v1/admin/rollovers", Tag: "admin", Summary: "Rollover runs, newest first",
+			Auth: true, Response: []models.RolloverRun{},
+			Query: []openapi.Parameter{openapi.QueryParam("school_id", "string", "only runs of this school")}},
+		{Method: http.MethodPost, Path: "/v1/admin/rollovers/:id/undo", Tag: "admin", Summary: "Undo the latest rollover of a school",
+			Auth: true, Response: models.RolloverRun{}, Errors: []int{http.StatusConflict}},
+	}
+
+	for _, table := range []handlers.NamedTable{handlers.SchoolsTable, handlers.ClassesTable, handlers.SubjectsTable} {
+		path := "/v1/admin/" + table.Table
+		ops = append(ops,
+			openapi.Operation{Method: http.MethodGet, Path: path, Tag: "admin", Summary: "List " + table.Table,
+				Auth: true, Response: []models.NamedEntity{}},
+			openapi.Operation{Method: http.MethodPost, Path: path, Tag: "admin", Summary: "Create a " + table.Label,
+				Auth: true, Request: models.NamedEntityRequest{}, Response: models.NamedEntity{}, Status: http.StatusCreated, Errors: []int{http.StatusConflict}},
+			openapi.Operation{Method: http.MethodGet, Path: path + "/:id", Tag: "admin", Summary: "Get a " + table.Label,
+				Auth: true, Response: models.NamedEntity{}},
+			openapi.Operation{Method: http.MethodPut, Path: path + "/:id", Tag: "admin", Summary: "Rename a " + table.Label,
+				Auth: true, Request: models.NamedEntityRequest{}, Response: models.NamedEntity{}, Errors: []int{http.StatusConflict}},
+			openapi.Operation{Method: http.MethodDelete, Path: path + "/:id", Tag: "admin", Summary: "Delete a " + table.Label + " no mapping uses",
+				Auth: true, Response: models.IDResponse{}, Errors: []int{http.StatusConflict}},
+		)
+	}
+
+	// every /v1 group runs under middleware.Timeout
+	for i := range ops {
+		ops[i].Errors = append(ops[i].Errors, http.StatusGatewayTimeout)
+	}
+	return ops
+}
+
+var (
+	documentOnce sync.Once
+	document     *openapi.Document
+)
+
+// APIDocument is the OpenAPI document served at /openapi.json.
+func APIDocument() *openapi.Document {
+	documentOnce.Do(func() {
+		document = openapi.Build(openapi.Info{Title: config.GetEnv().ServiceName + " API", Version: config.Version}, operations())
+	})
+	return document
+}
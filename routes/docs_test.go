@@ -0,0 +1,68 @@
+package routes_test
+
+import (
+	"backend/routes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestEveryRouteIsDocumented fails when a /v1 route is registered without an entry in
+// the OpenAPI document, or the document describes a route that does not exist.
+func TestEveryRouteIsDocumented(t *testing.T) {
+	doc := routes.APIDocument()
+	registered := map[string]bool{}
+	for _, route := range newFixture(t).router.Routes() {
+		if !strings.HasPrefix(route.Path, "/v1/") {
+			continue
+		}
+		registered[route.Method+" "+route.Path] = true
+		if !doc.Has(route.Method, route.Path) {
+			t.Errorf("%s %s is not documented in routes/docs.go", route.Method, route.Path)
+		}
+	}
+	for _, op := range doc.Operations() {
+		if !registered[op] {
+			t.Errorf("%s is documented but not registered", op)
+		}
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	f := newFixture(t)
+	rec := f.do(http.MethodGet, "/openapi.json", "", nil)
+	var doc struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Required []string `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decoding %s: %v", rec.Body.String(), err)
+	}
+	if doc.OpenAPI != "3.0.3" || doc.Paths["/v1/students/chats/{id}"]["get"] == nil {
+		t.Fatalf("got openapi %q with paths %v", doc.OpenAPI, doc.Paths)
+	}
+	for _, name := range []string{"Student", "PublicChat", "YearWiseDetails", "SCSDetail", "RosterReport", "Error"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("schema %s missing", name)
+		}
+	}
+	if got := doc.Components.Schemas["SCSMappingRequest"].Required; len(got) != 4 {
+		t.Errorf("SCSMappingRequest requires %v, want all four fields", got)
+	}
+
+	for url, contentType := range map[string]string{
+		"/docs/":                     "text/html",
+		"/docs/swagger-ui-bundle.js": "javascript",
+	} {
+		rec := f.do(http.MethodGet, url, "", nil)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Header().Get("Content-Type"), contentType) {
+			t.Errorf("%s: got %d %q", url, rec.Code, rec.Header().Get("Content-Type"))
+		}
+	}
+}
@@ -1,36 +1,90 @@
 package routes
 
 import (
+	"backend/answer"
+	"backend/apperror"
+	"backend/config"
 	"backend/controllers"
+	"backend/handlers"
+	"backend/health"
+	"backend/lifecycle"
+	"backend/metrics"
 	"backend/middleware"
+	"backend/models"
+	"backend/moderation"
+	"backend/openapi"
+	"backend/replica"
+	"backend/repository"
+	"backend/similarity"
+	"backend/validation"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func prepareV1Routes(router *gin.Engine, db *pgxpool.Pool) {
+// Services are long-lived components shared by the controllers.
+type Services struct {
+	Detector  *similarity.Detector
+	Answers   *answer.Worker
+	Moderator *moderation.Moderator
+	Readiness *lifecycle.Readiness
+	// Replicas, when set, keeps the reads of students who just wrote on the primary.
+	Replicas *replica.Router
+	// HealthChecks are the dependencies /readyz probes.
+	HealthChecks []health.Check
+}
+
+func prepareV1Routes(router *gin.Engine, repos repository.Set, services Services) {
 
 	v1 := router.Group("/v1")
+	env := config.GetEnv()
 
-	studentController := controllers.StudentController{DB: db}
-	teacherController := controllers.TeacherController{DB: db}
+	studentController := controllers.StudentController{
+		Accounts:   repos.Accounts,
+		Students:   repos.Students,
+		Chats:      repos.Chats,
+		Messages:   repos.Messages,
+		SCS:        repos.SCS,
+		Moderation: repos.Moderation,
+		Detector:   services.Detector,
+		Answers:    services.Answers,
+		Moderator:  services.Moderator,
+	}
+	authController := controllers.AuthController{Accounts: repos.Accounts}
+	teacherController := controllers.TeacherController{Accounts: repos.Accounts}
+	moderationController := controllers.ModerationController{Moderation: repos.Moderation, Answers: services.Answers}
+	adminController := controllers.AdminController{
+		Accounts:  repos.Accounts,
+		Admin:     repos.Admin,
+		Roster:    repos.Roster,
+		Rollovers: repos.Rollovers,
+	}
 
 	public := v1.Group("/public")
+	public.Use(middleware.Timeout(env.RequestTimeout))
 	{
 		public.POST("/students/login", studentController.Login)
 		public.POST("/teacher/login", teacherController.Login)
+		public.POST("/admin/login", adminController.Login)
+	}
+
+	auth := v1.Group("/auth")
+	auth.Use(middleware.Timeout(env.RequestTimeout), middleware.AuthMiddleware())
+	{
+		auth.POST("/refresh", authController.Refresh)
 	}
 
 	students := v1.Group("/students")
-	students.Use(middleware.AuthMiddleware())
+	students.Use(middleware.Timeout(env.RequestTimeout), middleware.AuthMiddleware(), middleware.RequireRole(config.RoleStudent), middleware.UUIDParams())
+	if services.Replicas != nil {
+		students.Use(middleware.ReadYourWrites(services.Replicas))
+	}
 	{
 		students.GET("/profile", func(ctx *gin.Context) {
-			userID := ctx.GetInt("user_id")
-			email := ctx.GetString("email")
-
-			ctx.JSON(200, gin.H{
-				"user_id": userID,
-				"email":   email,
+			ctx.JSON(http.StatusOK, gin.H{
+				"status": true,
+				"data":   models.TokenProfile{UserID: ctx.GetString("user_id"), Email: ctx.GetString("email")},
 			})
 		})
 		students.POST("/reset-password", studentController.ResetPassword)
@@ -38,15 +92,79 @@ func prepareV1Routes(router *gin.Engine, db *pgxpool.Pool) {
 		students.GET("/chats", studentController.GetChatList)
 		students.GET("/chats/:id", studentController.GetChatDetailsByID)
 		students.GET("/chats/:id/messages", studentController.GetChatMessages)
+		students.POST("/chats/:id/messages", studentController.AskQuestion)
 		students.GET("/scs_mapping", studentController.GetSCSMapping)
 	}
+
+	teachers := v1.Group("/teachers")
+	teachers.Use(middleware.Timeout(env.RequestTimeout), middleware.AuthMiddleware(), middleware.RequireRole(config.RoleTeacher), middleware.UUIDParams())
+	{
+		teachers.GET("/moderation/queue", moderationController.GetQueue)
+		teachers.POST("/moderation/queue/:id", moderationController.Review)
+		teachers.GET("/moderation/blocklist", moderationController.GetBlocklist)
+		teachers.POST("/moderation/blocklist", moderationController.AddBlocklistTerm)
+		teachers.DELETE("/moderation/blocklist/:id", moderationController.DeleteBlocklistTerm)
+	}
+
+	admin := v1.Group("/admin")
+	admin.Use(middleware.Timeout(env.AdminRequestTimeout))
+	if env.TLSClientCAFile != "" {
+		admin.Use(middleware.RequireClientCert())
+	}
+	admin.Use(middleware.AuthMiddleware(), middleware.RequireRole(config.RoleAdmin), middleware.UUIDParams())
+	{
+		for _, table := range []handlers.NamedTable{handlers.SchoolsTable, handlers.ClassesTable, handlers.SubjectsTable} {
+			path := "/" + table.Table
+			admin.GET(path, adminController.ListNamed(table))
+			admin.POST(path, adminController.CreateNamed(table))
+			admin.GET(path+"/:id", adminController.GetNamed(table))
+			admin.PUT(path+"/:id", adminController.UpdateNamed(table))
+			admin.DELETE(path+"/:id", adminController.DeleteNamed(table))
+		}
+
+		admin.GET("/scs", adminController.ListSCSMappings)
+		admin.POST("/scs", adminController.CreateSCSMapping)
+		admin.GET("/scs/:id", adminController.GetSCSMapping)
+		admin.PUT("/scs/:id", adminController.UpdateSCSMapping)
+		admin.DELETE("/scs/:id", adminController.DeleteSCSMapping)
+		admin.GET("/scs/:id/students", adminController.ListEnrollments)
+		admin.POST("/scs/:id/students", adminController.EnrollStudent)
+		admin.DELETE("/scs/:id/students/:student_id", adminController.UnenrollStudent)
+		admin.POST("/import/roster", adminController.ImportRoster)
+		admin.POST("/schools/:id/rollover", adminController.Rollover)
+		admin.GET("/rollovers", adminController.ListRollovers)
+		admin.POST("/rollovers/:id/undo", adminController.UndoRollover)
+	}
 }
 
-func SetupRoutes(db *pgxpool.Pool) *gin.Engine {
+func SetupRoutes(repos repository.Set, services Services) *gin.Engine {
+	validation.Register()
+
 	router := gin.New()
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
+	router.Use(otelgin.Middleware(config.GetEnv().ServiceName))
+	router.Use(middleware.RequestID())
+	router.Use(middleware.AccessLog())
+	router.Use(middleware.Recovery())
+	router.Use(metrics.Middleware())
 	router.Use(CORSMiddleware())
-	prepareV1Routes(router, db)
+
+	healthController := controllers.HealthController{
+		Ready:   services.Readiness,
+		Checks:  services.HealthChecks,
+		Timeout: config.GetEnv().HealthCheckTimeout,
+	}
+	router.GET("/healthz", healthController.Liveness)
+	router.GET("/readyz", healthController.Readiness)
+	router.GET("/version", healthController.Version)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	router.GET("/openapi.json", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, APIDocument())
+	})
+	router.GET("/docs/*filepath", openapi.UI("/openapi.json"))
+
+	prepareV1Routes(router, repos, services)
+	router.NoRoute(func(ctx *gin.Context) {
+		apperror.Respond(ctx, apperror.NotFound("route not found"))
+	})
 	return router
 }
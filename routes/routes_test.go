@@ -0,0 +1,578 @@
+package routes_test
+
+import (
+	"backend/apperror"
+	"backend/cache"
+	"backend/config"
+	"backend/handlers"
+	"backend/lifecycle"
+	"backend/middleware"
+	"backend/models"
+	"backend/moderation"
+	"backend/repository"
+	"backend/repository/memory"
+	"backend/routes"
+	"backend/similarity"
+	"backend/validation"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	config.InitLogger()
+	if err := config.ConfigureLogger("fatal", "json"); err != nil {
+		panic(err)
+	}
+	config.LoadEnv()
+	os.Exit(m.Run())
+}
+
+// fixture is a router backed by a memory store holding one school with a student
+// enrolled in a subject, a teacher of that school and an admin.
+type fixture struct {
+	t      *testing.T
+	router *gin.Engine
+	store  *memory.Store
+	repos  repository.Set
+	tokens map[string]string // by role
+
+	student, teacher, admin models.UserAccount
+	school, class, subject  models.NamedEntity
+	// spare are unused schools, classes and subjects by table, safe to delete
+	spare    map[string]models.NamedEntity
+	scs      models.SCSMapping
+	spareSCS models.SCSMapping
+	chat     models.PublicChat
+	answered models.PublicChatMessage
+	flagged  models.PublicChatMessage
+	term     models.BlocklistTerm
+	rollover string
+}
+
+const password = "secret-123"
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	return newCachedFixture(t, cache.Noop{})
+}
+
+// newCachedFixture serves the cacheable reads through c.
+func newCachedFixture(t *testing.T, c cache.Cache) *fixture {
+	t.Helper()
+	ctx := context.Background()
+	store := memory.NewStore()
+	repos := repository.Cached(memory.NewSet(store), cache.New(c), repository.CacheTTLs{Profile: time.Minute, SCS: time.Minute, Names: time.Minute})
+	f := &fixture{t: t, store: store, repos: repos, tokens: map[string]string{}, spare: map[string]models.NamedEntity{}}
+
+	f.school = f.named("schools", "Demo School")
+	f.class = f.named("classes", "Class 8")
+	f.subject = f.named("subjects", "Science")
+	f.spare["schools"] = f.named("schools", "Spare School")
+	f.spare["classes"] = f.named("classes", "Class 9")
+	f.spare["subjects"] = f.named("subjects", "History")
+
+	var err error
+	f.scs, err = store.CreateSCSMapping(ctx, models.SCSMappingRequest{SchoolID: f.school.ID, ClassID: f.class.ID, SubjectID: f.subject.ID, Year: 2025})
+	must(t, err)
+	f.spareSCS, err = store.CreateSCSMapping(ctx, models.SCSMappingRequest{SchoolID: f.school.ID, ClassID: f.class.ID, SubjectID: f.subject.ID, Year: 2024})
+	must(t, err)
+
+	f.student = f.user(models.CreateUserRequest{Role: config.RoleStudent, ExternalID: "S-1", FullName: "Asha", Email: "student@demo.local", Password: password})
+	f.teacher = f.user(models.CreateUserRequest{Role: config.RoleTeacher, ExternalID: "T-1", FullName: "Ravi", Email: "teacher@demo.local", School: f.school.ID, Password: password})
+	f.admin = f.user(models.CreateUserRequest{Role: config.RoleAdmin, FullName: "Meera", Email: "admin@demo.local", Password: password})
+
+	_, err = store.EnrollStudent(ctx, f.scs.ID, f.student.ID)
+	must(t, err)
+
+	f.chat = store.AddChat(models.PublicChat{StudentID: &f.student.ID, ScsID: &f.scs.ID})
+	answer := "Plants turn light into chemical energy."
+	f.answered, err = store.CreateChatMessage(ctx, f.chat.ID, "What is photosynthesis?", &answer, models.ModerationVerdict{Status: models.ModerationApproved, Reasons: []string{}})
+	must(t, err)
+	f.flagged, err = store.CreateChatMessage(ctx, f.chat.ID, "this homework is shit", nil, models.ModerationVerdict{Status: models.ModerationFlagged, Reasons: []string{moderation.ReasonProfanity}})
+	must(t, err)
+	f.term, err = store.AddBlocklistTerm(ctx, f.teacher.ID, "cheat")
+	must(t, err)
+
+	diff, err := f.repos.Rollovers.Run(ctx, f.school.ID, 2025, []string{"Class 8", "Class 9"}, f.admin.ID, false)
+	must(t, err)
+	f.rollover = diff.RunID
+
+	readiness := &lifecycle.Readiness{}
+	readiness.SetReady(true)
+	f.router = routes.SetupRoutes(f.repos, routes.Services{
+		Detector:  similarity.NewDetector(store, nil, nil, 0.6, 3),
+		Moderator: moderation.NewModerator(nil),
+		Readiness: readiness,
+	})
+	return f
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+var tables = map[string]handlers.NamedTable{
+	"schools":  handlers.SchoolsTable,
+	"classes":  handlers.ClassesTable,
+	"subjects": handlers.SubjectsTable,
+}
+
+func (f *fixture) named(table string, name string) models.NamedEntity {
+	f.t.Helper()
+	entity, err := f.store.CreateNamed(context.Background(), tables[table], name)
+	must(f.t, err)
+	return entity
+}
+
+func (f *fixture) user(req models.CreateUserRequest) models.UserAccount {
+	f.t.Helper()
+	account, err := f.store.CreateUser(context.Background(), req)
+	must(f.t, err)
+	f.tokens[req.Role] = f.token(account.ID, account.Email, req.Role)
+	return account
+}
+
+func (f *fixture) token(userID string, email string, role string) string {
+	f.t.Helper()
+	token, err := config.GenerateJWT(userID, email, role)
+	must(f.t, err)
+	return token
+}
+
+// upload is a multipart body with a single file field.
+type upload struct {
+	filename string
+	content  string
+}
+
+// do sends a request as role ("" for anonymous). body is encoded as JSON unless it is an upload.
+func (f *fixture) do(method string, url string, role string, body any) *httptest.ResponseRecorder {
+	f.t.Helper()
+	var reader io.Reader
+	contentType := "application/json"
+	switch b := body.(type) {
+	case nil:
+	case upload:
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		part, err := w.CreateFormFile("file", b.filename)
+		must(f.t, err)
+		_, err = part.Write([]byte(b.content))
+		must(f.t, err)
+		must(f.t, w.Close())
+		reader, contentType = &buf, w.FormDataContentType()
+	default:
+		encoded, err := json.Marshal(b)
+		must(f.t, err)
+		reader = bytes.NewReader(encoded)
+	}
+
+	req := httptest.NewRequest(method, url, reader)
+	req.Header.Set("Content-Type", contentType)
+	if role != "" {
+		req.Header.Set("Authorization", "Bearer "+f.tokens[role])
+	}
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// decode unmarshals the "data" field of a response.
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var body struct {
+		Data T `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding %s: %v", rec.Body.String(), err)
+	}
+	return body.Data
+}
+
+type routeCase struct {
+	name   string
+	method string
+	route  string // the registered path template
+	url    func(f *fixture) string
+	role   string
+	body   func(f *fixture) any
+	want   int
+}
+
+func path(p string) func(*fixture) string { return func(*fixture) string { return p } }
+
+func namedCases(table string) []routeCase {
+	base := "/v1/admin/" + table
+	spare := func(f *fixture) string { return base + "/" + f.spare[table].ID }
+	return []routeCase{
+		{name: "list " + table, method: http.MethodGet, route: base, url: path(base), role: config.RoleAdmin, want: http.StatusOK},
+		{name: "create " + table, method: http.MethodPost, route: base, url: path(base), role: config.RoleAdmin,
+			body: func(*fixture) any { return models.NamedEntityRequest{Name: "New " + table} }, want: http.StatusCreated},
+		{name: "get " + table, method: http.MethodGet, route: base + "/:id", url: spare, role: config.RoleAdmin, want: http.StatusOK},
+		{name: "update " + table, method: http.MethodPut, route: base + "/:id", url: spare, role: config.RoleAdmin,
+			body: func(*fixture) any { return models.NamedEntityRequest{Name: "Renamed"} }, want: http.StatusOK},
+		{name: "delete " + table, method: http.MethodDelete, route: base + "/:id", url: spare, role: config.RoleAdmin, want: http.StatusOK},
+	}
+}
+
+func routeCases() []routeCase {
+	login := func(role string) func(f *fixture) any {
+		return func(*fixture) any {
+			return models.StudentLoginRequest{Email: role + "@demo.local", Password: password}
+		}
+	}
+	chat := func(suffix string) func(f *fixture) string {
+		return func(f *fixture) string { return "/v1/students/chats/" + f.chat.ID + suffix }
+	}
+	scs := func(suffix string) func(f *fixture) string {
+		return func(f *fixture) string { return "/v1/admin/scs/" + f.scs.ID + suffix }
+	}
+
+	cases := []routeCase{
+		{name: "liveness", method: http.MethodGet, route: "/healthz", url: path("/healthz"), want: http.StatusOK},
+		{name: "readiness", method: http.MethodGet, route: "/readyz", url: path("/readyz"), want: http.StatusOK},
+		{name: "version", method: http.MethodGet, route: "/version", url: path("/version"), want: http.StatusOK},
+		{name: "metrics", method: http.MethodGet, route: "/metrics", url: path("/metrics"), want: http.StatusOK},
+		{name: "openapi", method: http.MethodGet, route: "/openapi.json", url: path("/openapi.json"), want: http.StatusOK},
+		{name: "docs", method: http.MethodGet, route: "/docs/*filepath", url: path("/docs/index.html"), want: http.StatusOK},
+
+		{name: "student login", method: http.MethodPost, route: "/v1/public/students/login", url: path("/v1/public/students/login"), body: login("student"), want: http.StatusOK},
+		{name: "teacher login", method: http.MethodPost, route: "/v1/public/teacher/login", url: path("/v1/public/teacher/login"), body: login("teacher"), want: http.StatusOK},
+		{name: "admin login", method: http.MethodPost, route: "/v1/public/admin/login", url: path("/v1/public/admin/login"), body: login("admin"), want: http.StatusOK},
+
+		{name: "refresh token", method: http.MethodPost, route: "/v1/auth/refresh", url: path("/v1/auth/refresh"), role: config.RoleTeacher, want: http.StatusOK},
+
+		{name: "student profile", method: http.MethodGet, route: "/v1/students/profile", url: path("/v1/students/profile"), role: config.RoleStudent, want: http.StatusOK},
+		{name: "student reset password", method: http.MethodPost, route: "/v1/students/reset-password", url: path("/v1/students/reset-password"), role: config.RoleStudent,
+			body: func(*fixture) any { return models.StudentResetPasswordRequest{Password: "changed-456"} }, want: http.StatusOK},
+		{name: "student details", method: http.MethodGet, route: "/v1/students/me", url: path("/v1/students/me"), role: config.RoleStudent, want: http.StatusOK},
+		{name: "chat list", method: http.MethodGet, route: "/v1/students/chats", url: path("/v1/students/chats"), role: config.RoleStudent, want: http.StatusOK},
+		{name: "chat details", method: http.MethodGet, route: "/v1/students/chats/:id", url: chat(""), role: config.RoleStudent, want: http.StatusOK},
+		{name: "chat messages", method: http.MethodGet, route: "/v1/students/chats/:id/messages", url: chat("/messages"), role: config.RoleStudent, want: http.StatusOK},
+		{name: "ask question", method: http.MethodPost, route: "/v1/students/chats/:id/messages", url: chat("/messages"), role: config.RoleStudent,
+			body: func(*fixture) any { return models.AskQuestionRequest{Question: "Why is the sky blue?"} }, want: http.StatusCreated},
+		{name: "scs mapping", method: http.MethodGet, route: "/v1/students/scs_mapping", url: path("/v1/students/scs_mapping"), role: config.RoleStudent, want: http.StatusOK},
+
+		{name: "moderation queue", method: http.MethodGet, route: "/v1/teachers/moderation/queue", url: path("/v1/teachers/moderation/queue"), role: config.RoleTeacher, want: http.StatusOK},
+		{name: "review message", method: http.MethodPost, route: "/v1/teachers/moderation/queue/:id",
+			url:  func(f *fixture) string { return "/v1/teachers/moderation/queue/" + f.flagged.ID },
+			role: config.RoleTeacher, body: func(*fixture) any { return models.ModerationReviewRequest{Decision: "reject"} }, want: http.StatusOK},
+		{name: "blocklist", method: http.MethodGet, route: "/v1/teachers/moderation/blocklist", url: path("/v1/teachers/moderation/blocklist"), role: config.RoleTeacher, want: http.StatusOK},
+		{name: "add blocklist term", method: http.MethodPost, route: "/v1/teachers/moderation/blocklist", url: path("/v1/teachers/moderation/blocklist"), role: config.RoleTeacher,
+			body: func(*fixture) any { return models.BlocklistTermRequest{Term: "copy"} }, want: http.StatusCreated},
+		{name: "delete blocklist term", method: http.MethodDelete, route: "/v1/teachers/moderation/blocklist/:id",
+			url:  func(f *fixture) string { return "/v1/teachers/moderation/blocklist/" + f.term.ID },
+			role: config.RoleTeacher, want: http.StatusOK},
+
+		{name: "list scs", method: http.MethodGet, route: "/v1/admin/scs", url: path("/v1/admin/scs?year=2025"), role: config.RoleAdmin, want: http.StatusOK},
+		{name: "create scs", method: http.MethodPost, route: "/v1/admin/scs", url: path("/v1/admin/scs"), role: config.RoleAdmin,
+			body: func(f *fixture) any {
+				return models.SCSMappingRequest{SchoolID: f.school.ID, ClassID: f.class.ID, SubjectID: f.subject.ID, Year: 2026}
+			}, want: http.StatusCreated},
+		{name: "get scs", method: http.MethodGet, route: "/v1/admin/scs/:id", url: scs(""), role: config.RoleAdmin, want: http.StatusOK},
+		{name: "update scs", method: http.MethodPut, route: "/v1/admin/scs/:id", url: scs(""), role: config.RoleAdmin,
+			body: func(f *fixture) any {
+				return models.SCSMappingRequest{SchoolID: f.school.ID, ClassID: f.class.ID, SubjectID: f.spare["subjects"].ID, Year: 2025}
+			}, want: http.StatusOK},
+		{name: "delete scs", method: http.MethodDelete, route: "/v1/admin/scs/:id",
+			url:  func(f *fixture) string { return "/v1/admin/scs/" + f.spareSCS.ID },
+			role: config.RoleAdmin, want: http.StatusOK},
+		{name: "list enrollments", method: http.MethodGet, route: "/v1/admin/scs/:id/students", url: scs("/students"), role: config.RoleAdmin, want: http.StatusOK},
+		{name: "enroll student", method: http.MethodPost, route: "/v1/admin/scs/:id/students",
+			url:  func(f *fixture) string { return "/v1/admin/scs/" + f.spareSCS.ID + "/students" },
+			role: config.RoleAdmin, body: func(f *fixture) any { return models.EnrollmentRequest{StudentID: f.student.ID} }, want: http.StatusOK},
+		{name: "unenroll student", method: http.MethodDelete, route: "/v1/admin/scs/:id/students/:student_id",
+			url:  func(f *fixture) string { return "/v1/admin/scs/" + f.scs.ID + "/students/" + f.student.ID },
+			role: config.RoleAdmin, want: http.StatusOK},
+		{name: "import roster", method: http.MethodPost, route: "/v1/admin/import/roster", url: path("/v1/admin/import/roster"), role: config.RoleAdmin,
+			body: func(*fixture) any {
+				return upload{filename: "roster.csv", content: "role,id,full_name,email,school\nstudent,S-2,Kiran,kiran@demo.local,Demo School\n"}
+			}, want: http.StatusOK},
+		{name: "rollover", method: http.MethodPost, route: "/v1/admin/schools/:id/rollover",
+			url:  func(f *fixture) string { return "/v1/admin/schools/" + f.school.ID + "/rollover" },
+			role: config.RoleAdmin, body: func(*fixture) any {
+				return models.RolloverRequest{FromYear: 2025, Ladder: []string{"Class 8", "Class 9"}}
+			}, want: http.StatusOK},
+		{name: "list rollovers", method: http.MethodGet, route: "/v1/admin/rollovers", url: path("/v1/admin/rollovers"), role: config.RoleAdmin, want: http.StatusOK},
+		{name: "undo rollover", method: http.MethodPost, route: "/v1/admin/rollovers/:id/undo",
+			url:  func(f *fixture) string { return "/v1/admin/rollovers/" + f.rollover + "/undo" },
+			role: config.RoleAdmin, want: http.StatusOK},
+	}
+	for _, table := range []string{"schools", "classes", "subjects"} {
+		cases = append(cases, namedCases(table)...)
+	}
+	return cases
+}
+
+func TestRoutes(t *testing.T) {
+	for _, tc := range routeCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t)
+			var body any
+			if tc.body != nil {
+				body = tc.body(f)
+			}
+			rec := f.do(tc.method, tc.url(f), tc.role, body)
+			if rec.Code != tc.want {
+				t.Fatalf("%s %s: got %d, want %d: %s", tc.method, tc.url(f), rec.Code, tc.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+// TestEveryRouteIsTested fails when a route is registered without a case in routeCases.
+func TestEveryRouteIsTested(t *testing.T) {
+	tested := map[string]bool{}
+	for _, tc := range routeCases() {
+		tested[tc.method+" "+tc.route] = true
+	}
+	for _, route := range newFixture(t).router.Routes() {
+		if !tested[route.Method+" "+route.Path] {
+			t.Errorf("%s %s has no test case", route.Method, route.Path)
+		}
+	}
+}
+
+var pathParam = regexp.MustCompile(`:[a-z_]+`)
+
+// anyRole marks routes open to every authenticated caller.
+const anyRole = "any"
+
+// roleOf returns the role a /v1 route group requires, or "" for public routes.
+func roleOf(route string) string {
+	switch {
+	case strings.HasPrefix(route, "/v1/auth/"):
+		return anyRole
+	case strings.HasPrefix(route, "/v1/students/"):
+		return config.RoleStudent
+	case strings.HasPrefix(route, "/v1/teachers/"):
+		return config.RoleTeacher
+	case strings.HasPrefix(route, "/v1/admin/"):
+		return config.RoleAdmin
+	}
+	return ""
+}
+
+func TestProtectedRoutesRejectUnauthorized(t *testing.T) {
+	f := newFixture(t)
+	f.tokens["expired"], _ = config.GenerateJWTWithTTL(f.student.ID, f.student.Email, config.RoleStudent, -1)
+	f.tokens["forged"] = f.tokens[config.RoleStudent][:len(f.tokens[config.RoleStudent])-4] + "AAAA"
+
+	for _, route := range f.router.Routes() {
+		required := roleOf(route.Path)
+		if required == "" {
+			continue
+		}
+		url := pathParam.ReplaceAllString(route.Path, "00000000-0000-4000-8000-000000000000")
+
+		for _, role := range []string{"", "expired", "forged"} {
+			if rec := f.do(route.Method, url, role, nil); rec.Code != http.StatusUnauthorized {
+				t.Errorf("%s %s with %q token: got %d, want 401", route.Method, route.Path, role, rec.Code)
+			}
+		}
+		for _, role := range []string{config.RoleStudent, config.RoleTeacher, config.RoleAdmin} {
+			if role == required || required == anyRole {
+				continue
+			}
+			if rec := f.do(route.Method, url, role, nil); rec.Code != http.StatusForbidden {
+				t.Errorf("%s %s as %s: got %d, want 403", route.Method, route.Path, role, rec.Code)
+			}
+		}
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	f := newFixture(t)
+	missingChat := "/v1/students/chats/00000000-0000-4000-8000-000000000000"
+	cases := []struct {
+		name   string
+		method string
+		url    string
+		role   string
+		want   int
+		code   apperror.Code
+	}{
+		{"missing chat", http.MethodGet, missingChat, config.RoleStudent, http.StatusNotFound, apperror.CodeNotFound},
+		{"messages of a missing chat", http.MethodGet, missingChat + "/messages", config.RoleStudent, http.StatusNotFound, apperror.CodeNotFound},
+		{"no token", http.MethodGet, "/v1/students/chats", "", http.StatusUnauthorized, apperror.CodeUnauthorized},
+		{"wrong role", http.MethodGet, "/v1/students/chats", config.RoleAdmin, http.StatusForbidden, apperror.CodeForbidden},
+		{"unknown route", http.MethodGet, "/v1/nothing", "", http.StatusNotFound, apperror.CodeNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := f.do(tc.method, tc.url, tc.role, nil)
+			var body struct {
+				Status bool          `json:"status"`
+				Error  apperror.Body `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding %s: %v", rec.Body.String(), err)
+			}
+			if rec.Code != tc.want || body.Status || body.Error.Code != tc.code || body.Error.Message == "" {
+				t.Fatalf("got %d %s", rec.Code, rec.Body.String())
+			}
+			if requestID := rec.Header().Get(middleware.RequestIDHeader); requestID == "" || body.Error.RequestID != requestID {
+				t.Fatalf("got request_id %q, header %q", body.Error.RequestID, requestID)
+			}
+		})
+	}
+}
+
+func TestValidationListsEveryInvalidField(t *testing.T) {
+	f := newFixture(t)
+	cases := []struct {
+		name   string
+		method string
+		url    string
+		role   string
+		body   any
+		fields []validation.FieldError
+	}{
+		{"login", http.MethodPost, "/v1/public/students/login", "", models.StudentLoginRequest{Email: "student"}, []validation.FieldError{
+			{Field: "email", Reason: "must be a valid email address"},
+			{Field: "password", Reason: "is required"},
+		}},
+		{"weak password", http.MethodPost, "/v1/students/reset-password", config.RoleStudent, models.StudentResetPasswordRequest{Password: "short"}, []validation.FieldError{
+			{Field: "password", Reason: "must be at least 8 characters and contain a letter and a digit"},
+		}},
+		{"mapping", http.MethodPost, "/v1/admin/scs", config.RoleAdmin, models.SCSMappingRequest{SchoolID: "demo", ClassID: f.class.ID, Year: 1999}, []validation.FieldError{
+			{Field: "school_id", Reason: "must be a UUID"},
+			{Field: "subject_id", Reason: "is required"},
+			{Field: "year", Reason: "must be at least 2000"},
+		}},
+		{"decision", http.MethodPost, "/v1/teachers/moderation/queue/" + f.flagged.ID, config.RoleTeacher, models.ModerationReviewRequest{Decision: "ignore"}, []validation.FieldError{
+			{Field: "decision", Reason: "must be one of: approve, reject"},
+		}},
+		{"malformed body", http.MethodPost, "/v1/admin/schools", config.RoleAdmin, []string{"Demo"}, []validation.FieldError{
+			{Field: "body", Reason: "must be a valid JSON object"},
+		}},
+		{"path parameter", http.MethodGet, "/v1/students/chats/42", config.RoleStudent, nil, []validation.FieldError{
+			{Field: "id", Reason: "must be a UUID"},
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := f.do(tc.method, tc.url, tc.role, tc.body)
+			var body struct {
+				Error struct {
+					Code    apperror.Code           `json:"code"`
+					Details []validation.FieldError `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding %s: %v", rec.Body.String(), err)
+			}
+			if rec.Code != http.StatusBadRequest || body.Error.Code != apperror.CodeValidation {
+				t.Fatalf("got %d: %s", rec.Code, rec.Body.String())
+			}
+			if !reflect.DeepEqual(body.Error.Details, tc.fields) {
+				t.Fatalf("got %+v, want %+v", body.Error.Details, tc.fields)
+			}
+		})
+	}
+}
+
+func TestCORSFollowsEnv(t *testing.T) {
+	saved := *config.GetEnv()
+	t.Cleanup(func() { *config.GetEnv() = saved })
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://*.demo.example.com")
+	t.Setenv("CORS_GROUP_ORIGINS", "admin=https://admin.example.com https://*.ops.example.com;public=*")
+	config.LoadEnv()
+
+	f := newFixture(t)
+	preflight := func(path string, origin string) int {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		req.Header.Set("Access-Control-Request-Headers", "Authorization")
+		rec := httptest.NewRecorder()
+		f.router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	for _, tc := range []struct {
+		path, origin string
+		want         int
+	}{
+		{"/v1/students/chats", "https://app.demo.example.com", http.StatusNoContent},
+		{"/v1/students/chats", "https://admin.example.com", http.StatusForbidden},
+		{"/v1/admin/scs", "https://admin.example.com", http.StatusNoContent},
+		{"/v1/admin/scs", "https://app.demo.example.com", http.StatusForbidden},
+		{"/v1/admin/scs", "https://eu.ops.example.com", http.StatusNoContent},
+		{"/v1/public/students/login", "https://anywhere.example.org", http.StatusNoContent},
+	} {
+		if got := preflight(tc.path, tc.origin); got != tc.want {
+			t.Errorf("%s from %s: got %d, want %d", tc.path, tc.origin, got, tc.want)
+		}
+	}
+
+	rec := f.do(http.MethodGet, "/healthz", "", nil)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("request without Origin: got Access-Control-Allow-Origin %q", got)
+	}
+}
+
+func TestAdminRequiresClientCertificateWithClientCA(t *testing.T) {
+	env := config.GetEnv()
+	saved := *env
+	t.Cleanup(func() { *env = saved })
+	env.TLSClientCAFile = "ca.crt"
+	f := newFixture(t)
+
+	request := func(url string, role string, verified bool) int {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Authorization", "Bearer "+f.tokens[role])
+		req.TLS = &tls.ConnectionState{}
+		if verified {
+			req.TLS.VerifiedChains = [][]*x509.Certificate{{{}}}
+		}
+		rec := httptest.NewRecorder()
+		f.router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if got := request("/v1/admin/schools", config.RoleAdmin, false); got != http.StatusForbidden {
+		t.Errorf("admin without client certificate: got %d, want 403", got)
+	}
+	if got := request("/v1/admin/schools", config.RoleAdmin, true); got != http.StatusOK {
+		t.Errorf("admin with client certificate: got %d, want 200", got)
+	}
+	if got := request("/v1/students/me", config.RoleStudent, false); got != http.StatusOK {
+		t.Errorf("student without client certificate: got %d, want 200", got)
+	}
+}
+
+func TestRedirectServerPointsToHTTPS(t *testing.T) {
+	env := config.GetEnv()
+	saved := *env
+	t.Cleanup(func() { *env = saved })
+
+	for port, want := range map[string]string{
+		"8443": "https://api.example.com:8443/v1/students/chats?limit=5",
+		"443":  "https://api.example.com/v1/students/chats?limit=5",
+	} {
+		env.GinPort = port
+		rec := httptest.NewRecorder()
+		routes.NewRedirectServer().Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "http://api.example.com:8080/v1/students/chats?limit=5", nil))
+		if rec.Code != http.StatusPermanentRedirect || rec.Header().Get("Location") != want {
+			t.Errorf("port %s: got %d to %q, want 308 to %q", port, rec.Code, rec.Header().Get("Location"), want)
+		}
+	}
+}
@@ -2,40 +2,83 @@ package routes
 
 import (
 	"backend/config"
+	"backend/middleware"
+	"backend/repository"
 	"fmt"
+	"net"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 )
 
+// CORSMiddleware applies the CORS policy of Env. CORS_GROUP_ORIGINS entries override
+// the allowed origins below /v1/<group>/.
 func CORSMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	env := config.GetEnv()
+	policy := middleware.CORSPolicy{
+		AllowedOrigins:   env.CORSAllowedOrigins,
+		AllowCredentials: env.CORSAllowCredentials,
+		AllowedMethods:   []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+		AllowedHeaders:   env.CORSAllowedHeaders,
+		ExposedHeaders:   env.CORSExposedHeaders,
+		MaxAge:           env.CORSMaxAge,
+	}
 
-		c.Next()
+	overrides := map[string]middleware.CORSPolicy{}
+	for group, origins := range env.CORSGroupOrigins {
+		override := policy
+		override.AllowedOrigins = origins
+		overrides["/v1/"+group+"/"] = override
 	}
+	return middleware.CORS(policy, overrides)
 }
 
-func InitServer(db *pgxpool.Pool) {
+// NewServer builds the HTTP server; the caller owns Serve and Shutdown.
+func NewServer(repos repository.Set, services Services) *http.Server {
 	globalEnv := config.GetEnv()
 
-	config.GetLogger().Info("v0.1.0")
+	config.GetLogger().Info("build info",
+		zap.String("version", config.Version),
+		zap.String("commit", config.Commit),
+		zap.String("build_time", config.BuildTime),
+	)
 
 	config.GetLogger().Info("Initializing API routes")
-	router := SetupRoutes(db)
+	router := SetupRoutes(repos, services)
 
-	config.GetLogger().Info("Starting up Gin server")
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%s", globalEnv.GinPort),
+		Handler:           router,
+		ReadTimeout:       globalEnv.HTTPReadTimeout,
+		ReadHeaderTimeout: globalEnv.HTTPReadHeaderTimeout,
+		WriteTimeout:      globalEnv.HTTPWriteTimeout,
+		IdleTimeout:       globalEnv.HTTPIdleTimeout,
+	}
+}
 
-	err := router.Run(fmt.Sprintf(":%s", globalEnv.GinPort))
-	if err != nil {
-		config.GetLogger().Fatal("failed to run HTTP server: %s", zap.Error(err))
+// NewRedirectServer answers plain HTTP on HTTPRedirectPort with a permanent redirect to
+// the same URL on the HTTPS listener.
+func NewRedirectServer() *http.Server {
+	env := config.GetEnv()
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%s", env.HTTPRedirectPort),
+		Handler:           redirectToHTTPS(env.GinPort),
+		ReadHeaderTimeout: env.HTTPReadHeaderTimeout,
+		IdleTimeout:       env.HTTPIdleTimeout,
 	}
 }
+
+func redirectToHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		// 308 keeps the method and body, unlike 301
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
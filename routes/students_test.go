@@ -0,0 +1,304 @@
+package routes_test
+
+import (
+	"backend/config"
+	"backend/models"
+	"backend/routes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLoginRejectsInvalidCredentials(t *testing.T) {
+	f := newFixture(t)
+	_, err := f.store.DisableUser(context.Background(), config.RoleTeacher, f.teacher.Email)
+	must(t, err)
+
+	cases := []struct {
+		name string
+		url  string
+		body any
+		want int
+	}{
+		{"wrong password", "/v1/public/students/login", models.StudentLoginRequest{Email: f.student.Email, Password: "wrong"}, http.StatusUnauthorized},
+		{"unknown email", "/v1/public/admin/login", models.StudentLoginRequest{Email: "nobody@demo.local", Password: password}, http.StatusUnauthorized},
+		{"other role", "/v1/public/admin/login", models.StudentLoginRequest{Email: f.student.Email, Password: password}, http.StatusUnauthorized},
+		{"disabled account", "/v1/public/teacher/login", models.StudentLoginRequest{Email: f.teacher.Email, Password: password}, http.StatusUnauthorized},
+		{"malformed body", "/v1/public/students/login", "not an object", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if rec := f.do(http.MethodPost, tc.url, "", tc.body); rec.Code != tc.want {
+				t.Fatalf("got %d, want %d: %s", rec.Code, tc.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestResetPasswordChangesLogin(t *testing.T) {
+	f := newFixture(t)
+	rec := f.do(http.MethodPost, "/v1/students/reset-password", config.RoleStudent, models.StudentResetPasswordRequest{Password: "changed-456"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("reset: got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	old := f.do(http.MethodPost, "/v1/public/students/login", "", models.StudentLoginRequest{Email: f.student.Email, Password: password})
+	if old.Code != http.StatusUnauthorized {
+		t.Errorf("old password: got %d, want 401", old.Code)
+	}
+	changed := f.do(http.MethodPost, "/v1/public/students/login", "", models.StudentLoginRequest{Email: f.student.Email, Password: "changed-456"})
+	if changed.Code != http.StatusOK {
+		t.Errorf("new password: got %d, want 200", changed.Code)
+	}
+}
+
+func TestRefreshTokenKeepsClaims(t *testing.T) {
+	f := newFixture(t)
+	refreshed := decode[models.TokenResponse](t, f.do(http.MethodPost, "/v1/auth/refresh", config.RoleStudent, nil))
+	if refreshed.Token == "" {
+		t.Fatal("got no token")
+	}
+
+	f.tokens[config.RoleStudent] = refreshed.Token
+	profile := decode[models.TokenProfile](t, f.do(http.MethodGet, "/v1/students/profile", config.RoleStudent, nil))
+	if profile.UserID != f.student.ID || profile.Email != f.student.Email {
+		t.Fatalf("got profile %+v", profile)
+	}
+}
+
+func TestRefreshRejectsDisabledAccounts(t *testing.T) {
+	f := newFixture(t)
+	if _, err := f.store.DisableUser(context.Background(), config.RoleTeacher, f.teacher.Email); err != nil {
+		t.Fatal(err)
+	}
+	if rec := f.do(http.MethodPost, "/v1/auth/refresh", config.RoleTeacher, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("disabled account: got %d, want 401", rec.Code)
+	}
+
+	// a token for an id that is not an account of its role
+	f.tokens[config.RoleStudent] = f.token(f.teacher.ID, f.teacher.Email, config.RoleStudent)
+	if rec := f.do(http.MethodPost, "/v1/auth/refresh", config.RoleStudent, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unknown account: got %d, want 401", rec.Code)
+	}
+
+	f.tokens[config.RoleAdmin] = f.token(f.admin.ID, f.admin.Email, "")
+	if rec := f.do(http.MethodPost, "/v1/auth/refresh", config.RoleAdmin, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("token without a role: got %d, want 401", rec.Code)
+	}
+}
+
+func TestRolelessTokensDuringTransition(t *testing.T) {
+	env := config.GetEnv()
+	saved := *env
+	t.Cleanup(func() { *env = saved })
+
+	f := newFixture(t)
+	// tokens issued before roles existed carry no role claim
+	f.tokens[config.RoleStudent] = f.token(f.student.ID, f.student.Email, "")
+
+	if rec := f.do(http.MethodGet, "/v1/students/profile", config.RoleStudent, nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("without a transition window: got %d, want 403", rec.Code)
+	}
+
+	env.LegacyStudentTokensUntil = time.Now().Add(time.Hour)
+	if rec := f.do(http.MethodGet, "/v1/students/profile", config.RoleStudent, nil); rec.Code != http.StatusOK {
+		t.Fatalf("during the transition window: got %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	f.tokens[config.RoleTeacher] = f.tokens[config.RoleStudent]
+	if rec := f.do(http.MethodGet, "/v1/teachers/moderation/queue", config.RoleTeacher, nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("roleless token on a teacher route: got %d, want 403", rec.Code)
+	}
+
+	env.LegacyStudentTokensUntil = time.Now().Add(-time.Minute)
+	if rec := f.do(http.MethodGet, "/v1/students/profile", config.RoleStudent, nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("after the transition window: got %d, want 403", rec.Code)
+	}
+}
+
+func TestChatMessagesArePaginated(t *testing.T) {
+	f := newFixture(t)
+	url := "/v1/students/chats/" + f.chat.ID + "/messages"
+	page := decode[[]models.PublicChatMessage](t, f.do(http.MethodGet, url+"?limit=1", config.RoleStudent, nil))
+	if len(page) != 1 || page[0].ID != f.flagged.ID {
+		t.Fatalf("first page: got %+v, want the newest message", page)
+	}
+	page = decode[[]models.PublicChatMessage](t, f.do(http.MethodGet, url+"?limit=1&offset=1", config.RoleStudent, nil))
+	if len(page) != 1 || page[0].ID != f.answered.ID {
+		t.Fatalf("second page: got %+v", page)
+	}
+	if all := decode[[]models.PublicChatMessage](t, f.do(http.MethodGet, url, config.RoleStudent, nil)); len(all) != 2 {
+		t.Fatalf("without limit: got %d messages, want 2", len(all))
+	}
+
+	for _, query := range []string{"?limit=0", "?limit=101", "?limit=ten", "?offset=-1"} {
+		if rec := f.do(http.MethodGet, url+query, config.RoleStudent, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestStudentCannotReadAnotherStudentsChat(t *testing.T) {
+	f := newFixture(t)
+	rec := f.do(http.MethodGet, "/v1/students/chats/"+f.chat.ID, config.RoleStudent, nil)
+	if got := decode[models.PublicChat](t, rec); got.ID != f.chat.ID {
+		t.Fatalf("owner: got chat %q, want %q", got.ID, f.chat.ID)
+	}
+
+	// f.user replaces the student token with the other student's
+	other := f.user(models.CreateUserRequest{Role: config.RoleStudent, ExternalID: "S-2", FullName: "Kiran", Email: "kiran@demo.local", Password: password})
+	if rec := f.do(http.MethodGet, "/v1/students/chats/"+f.chat.ID, config.RoleStudent, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("student %s read a chat of %s: got %d, want 404", other.ID, f.student.ID, rec.Code)
+	}
+	chats := decode[[]models.PublicChat](t, f.do(http.MethodGet, "/v1/students/chats", config.RoleStudent, nil))
+	if len(chats) != 0 {
+		t.Fatalf("got %d chats of another student", len(chats))
+	}
+}
+
+func TestSCSMappingGroupsByYear(t *testing.T) {
+	f := newFixture(t)
+	details := decode[[]models.YearWiseDetails](t, f.do(http.MethodGet, "/v1/students/scs_mapping", config.RoleStudent, nil))
+	if len(details) != 1 || details[0].Year != 2025 || !details[0].IsActive {
+		t.Fatalf("got %+v, want one active 2025 group", details)
+	}
+	if d := details[0].Details; len(d) != 1 || d[0].School != f.school.Name || d[0].Subject != f.subject.Name {
+		t.Fatalf("got details %+v", d)
+	}
+}
+
+type askResult struct {
+	Duplicate bool                     `json:"duplicate"`
+	Matches   []models.SimilarQuestion `json:"matches"`
+	Message   models.PublicChatMessage `json:"message"`
+}
+
+func TestAskQuestion(t *testing.T) {
+	cases := []struct {
+		name  string
+		req   func(f *fixture) models.AskQuestionRequest
+		want  int
+		check func(t *testing.T, f *fixture, got askResult)
+	}{
+		{
+			name: "new question is stored unanswered",
+			req: func(*fixture) models.AskQuestionRequest {
+				return models.AskQuestionRequest{Question: "  Why is the sky blue? "}
+			},
+			want: http.StatusCreated,
+			check: func(t *testing.T, f *fixture, got askResult) {
+				if got.Duplicate || got.Message.Question != "Why is the sky blue?" || got.Message.Answer != nil {
+					t.Errorf("got %+v", got)
+				}
+			},
+		},
+		{
+			name: "duplicate returns the existing answer",
+			req: func(*fixture) models.AskQuestionRequest {
+				return models.AskQuestionRequest{Question: "what is photosynthesis?"}
+			},
+			want: http.StatusOK,
+			check: func(t *testing.T, f *fixture, got askResult) {
+				if !got.Duplicate || len(got.Matches) != 1 || got.Matches[0].MessageID != f.answered.ID {
+					t.Errorf("got %+v", got)
+				}
+			},
+		},
+		{
+			name: "force skips the duplicate lookup",
+			req: func(*fixture) models.AskQuestionRequest {
+				return models.AskQuestionRequest{Question: "What is photosynthesis?", Force: true}
+			},
+			want: http.StatusCreated,
+			check: func(t *testing.T, f *fixture, got askResult) {
+				if got.Duplicate || got.Message.Answer != nil {
+					t.Errorf("got %+v", got)
+				}
+			},
+		},
+		{
+			name: "reuse copies the accepted answer",
+			req: func(f *fixture) models.AskQuestionRequest {
+				return models.AskQuestionRequest{Question: "What is photosynthesis?", ReuseMessageID: f.answered.ID}
+			},
+			want: http.StatusCreated,
+			check: func(t *testing.T, f *fixture, got askResult) {
+				if got.Message.Answer == nil || *got.Message.Answer != *f.answered.Answer {
+					t.Errorf("got %+v", got.Message)
+				}
+			},
+		},
+		{
+			name: "flagged question is queued for review",
+			req: func(*fixture) models.AskQuestionRequest {
+				return models.AskQuestionRequest{Question: "how do I cheat on the test"}
+			},
+			want: http.StatusCreated,
+			check: func(t *testing.T, f *fixture, got askResult) {
+				if got.Message.ModerationStatus != models.ModerationFlagged {
+					t.Errorf("got status %q, want flagged", got.Message.ModerationStatus)
+				}
+			},
+		},
+		{
+			name: "personal data is redacted",
+			req: func(*fixture) models.AskQuestionRequest {
+				return models.AskQuestionRequest{Question: "mail me at asha@example.com about fractions", Force: true}
+			},
+			want: http.StatusCreated,
+			check: func(t *testing.T, f *fixture, got askResult) {
+				if got.Message.Question == "mail me at asha@example.com about fractions" {
+					t.Errorf("email was stored: %q", got.Message.Question)
+				}
+			},
+		},
+		{
+			name: "empty question",
+			req:  func(*fixture) models.AskQuestionRequest { return models.AskQuestionRequest{Question: "   "} },
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "unknown reused message",
+			req: func(f *fixture) models.AskQuestionRequest {
+				return models.AskQuestionRequest{Question: "What is photosynthesis?", ReuseMessageID: f.flagged.ID}
+			},
+			want: http.StatusNotFound,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t)
+			rec := f.do(http.MethodPost, "/v1/students/chats/"+f.chat.ID+"/messages", config.RoleStudent, tc.req(f))
+			if rec.Code != tc.want {
+				t.Fatalf("got %d, want %d: %s", rec.Code, tc.want, rec.Body.String())
+			}
+			if tc.check != nil {
+				tc.check(t, f, decode[askResult](t, rec))
+			}
+		})
+	}
+}
+
+// slowStudents blocks until the request deadline passes.
+type slowStudents struct{}
+
+func (slowStudents) FetchStudentByID(ctx context.Context, id string) (models.Student, error) {
+	<-ctx.Done()
+	return models.Student{}, ctx.Err()
+}
+
+func TestRequestTimeoutReturnsGatewayTimeout(t *testing.T) {
+	f := newFixture(t)
+	env := config.GetEnv()
+	previous := env.RequestTimeout
+	env.RequestTimeout = 10 * time.Millisecond
+	defer func() { env.RequestTimeout = previous }()
+
+	repos := f.repos
+	repos.Students = slowStudents{}
+	f.router = routes.SetupRoutes(repos, routes.Services{})
+
+	if rec := f.do(http.MethodGet, "/v1/students/me", config.RoleStudent, nil); rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("got %d, want 504: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,93 @@
+package routes_test
+
+import (
+	"backend/config"
+	"backend/models"
+	"net/http"
+	"testing"
+)
+
+func TestModerationQueueIsScopedToTeacherSchool(t *testing.T) {
+	f := newFixture(t)
+	queue := decode[[]models.FlaggedMessage](t, f.do(http.MethodGet, "/v1/teachers/moderation/queue", config.RoleTeacher, nil))
+	if len(queue) != 1 || queue[0].ID != f.flagged.ID {
+		t.Fatalf("got %+v, want the flagged message", queue)
+	}
+
+	// a teacher of another school sees nothing and cannot review
+	f.user(models.CreateUserRequest{Role: config.RoleTeacher, ExternalID: "T-2", FullName: "Nila", Email: "nila@demo.local", School: f.spare["schools"].ID, Password: password})
+	queue = decode[[]models.FlaggedMessage](t, f.do(http.MethodGet, "/v1/teachers/moderation/queue", config.RoleTeacher, nil))
+	if len(queue) != 0 {
+		t.Fatalf("got %d messages of another school", len(queue))
+	}
+	rec := f.do(http.MethodPost, "/v1/teachers/moderation/queue/"+f.flagged.ID, config.RoleTeacher, models.ModerationReviewRequest{Decision: "approve"})
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("review out of scope: got %d, want 404", rec.Code)
+	}
+}
+
+func TestReviewMessage(t *testing.T) {
+	cases := []struct {
+		decision   string
+		want       int
+		wantStatus string
+	}{
+		{"approve", http.StatusOK, models.ModerationApproved},
+		{"reject", http.StatusOK, models.ModerationRejected},
+		{"ignore", http.StatusBadRequest, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.decision, func(t *testing.T) {
+			f := newFixture(t)
+			url := "/v1/teachers/moderation/queue/" + f.flagged.ID
+			rec := f.do(http.MethodPost, url, config.RoleTeacher, models.ModerationReviewRequest{Decision: tc.decision})
+			if rec.Code != tc.want {
+				t.Fatalf("got %d, want %d: %s", rec.Code, tc.want, rec.Body.String())
+			}
+			if tc.wantStatus == "" {
+				return
+			}
+			got := decode[models.FlaggedMessage](t, rec)
+			if got.ModerationStatus != tc.wantStatus || got.ReviewedBy == nil || *got.ReviewedBy != f.teacher.ID {
+				t.Fatalf("got %+v", got)
+			}
+			// a reviewed message leaves the queue
+			if again := f.do(http.MethodPost, url, config.RoleTeacher, models.ModerationReviewRequest{Decision: tc.decision}); again.Code != http.StatusNotFound {
+				t.Fatalf("second review: got %d, want 404", again.Code)
+			}
+		})
+	}
+}
+
+func TestBlocklist(t *testing.T) {
+	f := newFixture(t)
+	rec := f.do(http.MethodPost, "/v1/teachers/moderation/blocklist", config.RoleTeacher, models.BlocklistTermRequest{Term: "  Copy  "})
+	if got := decode[models.BlocklistTerm](t, rec); got.Term != "copy" || got.SchoolID != f.school.ID {
+		t.Fatalf("got %+v", got)
+	}
+	if rec := f.do(http.MethodPost, "/v1/teachers/moderation/blocklist", config.RoleTeacher, models.BlocklistTermRequest{Term: " "}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("blank term: got %d, want 400", rec.Code)
+	}
+
+	terms := decode[[]models.BlocklistTerm](t, f.do(http.MethodGet, "/v1/teachers/moderation/blocklist", config.RoleTeacher, nil))
+	if len(terms) != 2 || terms[0].Term != "cheat" || terms[1].Term != "copy" {
+		t.Fatalf("got %+v", terms)
+	}
+
+	url := "/v1/teachers/moderation/blocklist/" + f.term.ID
+	if rec := f.do(http.MethodDelete, url, config.RoleTeacher, nil); rec.Code != http.StatusOK {
+		t.Fatalf("delete: got %d", rec.Code)
+	}
+	if rec := f.do(http.MethodDelete, url, config.RoleTeacher, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("second delete: got %d, want 404", rec.Code)
+	}
+}
+
+func TestBlocklistRequiresSchool(t *testing.T) {
+	f := newFixture(t)
+	f.user(models.CreateUserRequest{Role: config.RoleTeacher, ExternalID: "T-3", FullName: "Arun", Email: "arun@demo.local", Password: password})
+	rec := f.do(http.MethodPost, "/v1/teachers/moderation/blocklist", config.RoleTeacher, models.BlocklistTermRequest{Term: "copy"})
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("got %d, want 403", rec.Code)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"backend/config"
+	"context"
+	"flag"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type seedResult struct {
+	SchoolID string            `json:"school_id"`
+	ClassIDs map[string]string `json:"class_ids"`
+	Subjects map[string]string `json:"subject_ids"`
+	Year     int               `json:"year"`
+	Password string            `json:"password"`
+	Students []string          `json:"students"`
+	Teachers []string          `json:"teachers"`
+	Admins   []string          `json:"admins"`
+}
+
+var (
+	seedClasses  = []string{"Class 8", "Class 9", "Class 10"}
+	seedSubjects = []string{"Mathematics", "Science", "English"}
+)
+
+// seed inserts a small demo dataset. It is idempotent: existing rows are reused.
+func seed(args []string) int {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	year := flags.Int("year", time.Now().Year(), "academic year of the demo mappings")
+	password := flags.String("password", "", "password for the demo users, generated when empty")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *password == "" {
+		*password = generatePassword()
+	}
+
+	ctx := context.Background()
+	pool, err := config.Connect(ctx)
+	if err != nil {
+		return printError(err)
+	}
+	defer pool.Close()
+
+	result := seedResult{
+		ClassIDs: map[string]string{},
+		Subjects: map[string]string{},
+		Year:     *year,
+		Password: *password,
+	}
+	err = pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		upsertNamed := func(table string, name string) (string, error) {
+			var id string
+			err := tx.QueryRow(ctx,
+				`INSERT INTO `+table+` (name) VALUES ($1) ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name RETURNING id`,
+				name,
+			).Scan(&id)
+			return id, err
+		}
+
+		var err error
+		if result.SchoolID, err = upsertNamed("schools", "Demo School"); err != nil {
+			return err
+		}
+		for _, name := range seedClasses {
+			if result.ClassIDs[name], err = upsertNamed("classes", name); err != nil {
+				return err
+			}
+		}
+		for _, name := range seedSubjects {
+			if result.Subjects[name], err = upsertNamed("subjects", name); err != nil {
+				return err
+			}
+		}
+
+		var firstScsIDs []string
+		for i, class := range seedClasses {
+			for _, subject := range seedSubjects {
+				var scsID string
+				err := tx.QueryRow(ctx, `
+					INSERT INTO school_class_subject_mapping (school_id, class_id, subject_id, year)
+					VALUES ($1, $2, $3, $4)
+					ON CONFLICT (school_id, class_id, subject_id, year) DO UPDATE SET year = EXCLUDED.year
+					RETURNING id
+				`, result.SchoolID, result.ClassIDs[class], result.Subjects[subject], *year).Scan(&scsID)
+				if err != nil {
+					return err
+				}
+				if i == 0 {
+					firstScsIDs = append(firstScsIDs, scsID)
+				}
+			}
+		}
+
+		var studentID string
+		err = tx.QueryRow(ctx, `
+			INSERT INTO students (student_id, full_name, email, phone_number, password)
+			VALUES ('DEMO-S-001', 'Demo Student', 'student@demo.local', '', $1)
+			ON CONFLICT (email) DO UPDATE SET password = EXCLUDED.password
+			RETURNING id
+		`, *password).Scan(&studentID)
+		if err != nil {
+			return err
+		}
+		for _, scsID := range firstScsIDs {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO student_scs_mapping (student_id, scs_id, is_active) VALUES ($1, $2, true)
+				ON CONFLICT (student_id, scs_id) DO NOTHING
+			`, studentID, scsID)
+			if err != nil {
+				return err
+			}
+		}
+		result.Students = append(result.Students, "student@demo.local")
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO teachers (teacher_id, full_name, email, phone, school, password)
+			VALUES ('DEMO-T-001', 'Demo Teacher', 'teacher@demo.local', '', $1, $2)
+			ON CONFLICT (email) DO UPDATE SET password = EXCLUDED.password
+		`, result.SchoolID, *password)
+		if err != nil {
+			return err
+		}
+		result.Teachers = append(result.Teachers, "teacher@demo.local")
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO admins (full_name, email, password)
+			VALUES ('Demo Admin', 'admin@demo.local', $1)
+			ON CONFLICT (email) DO UPDATE SET password = EXCLUDED.password
+		`, *password)
+		if err != nil {
+			return err
+		}
+		result.Admins = append(result.Admins, "admin@demo.local")
+		return nil
+	})
+	if err != nil {
+		return printError(err)
+	}
+	printJSON(result)
+	return exitOK
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"backend/answer"
+	"backend/cache"
+	"backend/certs"
+	"backend/config"
+	"backend/handlers"
+	"backend/health"
+	"backend/lifecycle"
+	"backend/metrics"
+	"backend/migrations"
+	"backend/moderation"
+	"backend/replica"
+	"backend/repository"
+	"backend/routes"
+	"backend/similarity"
+	"backend/tracing"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+func serve(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	runMigrations := flags.Bool("migrate", config.GetEnv().MigrateOnStartup, "apply pending migrations before serving")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	env := config.GetEnv()
+	config.GetLogger().Info("effective config", zap.Any("config", env.Redacted()))
+	ctx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	shutdownTracing, err := tracing.Init(ctx)
+	if err != nil {
+		return printError(err)
+	}
+
+	// Connect to database
+	pool, err := config.Connect(ctx)
+	if err != nil {
+		return printError(err)
+	}
+	defer pool.Close()
+	metrics.RegisterPool(pool)
+
+	if *runMigrations {
+		migrateOnStartup(pool)
+	}
+
+	replicaPools, err := config.ConnectReplicas(ctx)
+	if err != nil {
+		return printError(err)
+	}
+	replicas := replica.NewRouter(pool, replicaPools, env.DBReplicaMaxLag, env.DBReadYourWritesWindow)
+	defer replicas.Close()
+	if len(replicaPools) > 0 {
+		replicas.CheckLag(ctx)
+		go replicas.Watch(ctx, env.DBReplicaCheckInterval)
+	}
+
+	studentHandler := &handlers.StudentHandler{DB: pool, Replicas: replicas}
+	detector := similarity.NewDetector(
+		studentHandler,
+		similarity.NewHashingEmbedder(env.EmbeddingDimensions),
+		similarity.NewMemoryIndex(),
+		env.DuplicateMinScore,
+		env.DuplicateLimit,
+	)
+	warmSimilarityIndex(ctx, detector, studentHandler)
+
+	services := routes.Services{
+		Detector:  detector,
+		Moderator: moderation.NewModerator(env.ModerationBlocklist),
+		Readiness: &lifecycle.Readiness{},
+	}
+	if len(replicaPools) > 0 {
+		services.Replicas = replicas
+	}
+	if env.AnswerEngineURL != "" {
+		worker := answer.NewWorker(answer.NewHTTPEngine(env.AnswerEngineURL, env.AnswerEngineTimeout), studentHandler, env.AnswerQueueSize)
+		worker.OnAnswered = func(ctx context.Context, q answer.Question, text string) {
+			if q.ScsID == "" {
+				return
+			}
+			if err := detector.Remember(ctx, q.ScsID, q.MessageID, q.ChatID, q.Text, text); err != nil {
+				config.GetLogger().Warn("similarity_index_failed", zap.String("message_id", q.MessageID), zap.Error(err))
+			}
+		}
+		metrics.RegisterAnswerQueue(worker.QueueDepth)
+		go worker.Run(context.Background())
+		services.Answers = worker
+	}
+
+	var mc *memcache.Client
+	if len(env.MemcacheServers) > 0 {
+		mc = memcache.New(env.MemcacheServers...)
+	}
+	services.HealthChecks = healthChecks(pool, mc, services)
+
+	repos := repository.Cached(repository.NewPostgres(pool, replicas), newCacheStore(mc), repository.CacheTTLs{
+		Profile: env.CacheProfileTTL,
+		SCS:     env.CacheSCSTTL,
+		Names:   env.CacheNamesTTL,
+	})
+	server := routes.NewServer(repos, services)
+	var redirect *http.Server
+	if env.TLSEnabled() {
+		reloader, err := certs.NewReloader(env.TLSCertFile, env.TLSKeyFile, env.TLSClientCAFile)
+		if err != nil {
+			return printError(err)
+		}
+		server.TLSConfig = reloader.TLSConfig()
+		go reloader.Watch(ctx, env.TLSReloadInterval)
+		if env.HTTPRedirectPort != "" {
+			redirect = routes.NewRedirectServer()
+		}
+	}
+
+	// bind before reporting ready, so a busy port fails startup instead of a readiness probe
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		config.GetLogger().Error("failed to run HTTP server", zap.Error(err))
+		shutdown(server, nil, services, pool, shutdownTracing, false)
+		return exitFailure
+	}
+	var redirectListener net.Listener
+	if redirect != nil {
+		if redirectListener, err = net.Listen("tcp", redirect.Addr); err != nil {
+			listener.Close()
+			config.GetLogger().Error("failed to run HTTP redirect server", zap.Error(err))
+			shutdown(server, nil, services, pool, shutdownTracing, false)
+			return exitFailure
+		}
+	}
+
+	serveErr := make(chan error, 2)
+	go func() {
+		config.GetLogger().Info("Starting up Gin server", zap.String("addr", listener.Addr().String()), zap.Bool("tls", env.TLSEnabled()))
+		if env.TLSEnabled() {
+			// the certificate comes from server.TLSConfig
+			serveErr <- server.ServeTLS(listener, "", "")
+			return
+		}
+		serveErr <- server.Serve(listener)
+	}()
+	if redirect != nil {
+		go func() {
+			config.GetLogger().Info("redirecting HTTP to HTTPS", zap.String("addr", redirectListener.Addr().String()))
+			serveErr <- redirect.Serve(redirectListener)
+		}()
+	}
+	services.Readiness.SetReady(true)
+
+	exitCode := exitOK
+	select {
+	case err := <-serveErr:
+		config.GetLogger().Error("failed to run HTTP server", zap.Error(err))
+		exitCode = exitFailure
+	case <-ctx.Done():
+		config.GetLogger().Info("shutdown signal received")
+	}
+	shutdown(server, redirect, services, pool, shutdownTracing, true)
+	return exitCode
+}
+
+// shutdown stops the process in dependency order: stop advertising readiness, keep
+// serving through the drain period, finish in-flight requests, stop background
+// workers, close the database pool and flush pending spans. A server that never
+// started has no traffic to drain.
+func shutdown(server *http.Server, redirect *http.Server, services routes.Services, pool *pgxpool.Pool, shutdownTracing func(context.Context) error, started bool) {
+	env := config.GetEnv()
+	logger := config.GetLogger()
+
+	services.Readiness.SetReady(false)
+	if started {
+		logger.Info("draining", zap.Duration("period", env.ShutdownDrainPeriod))
+		time.Sleep(env.ShutdownDrainPeriod)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), env.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Error("http_shutdown_failed", zap.Error(err))
+	}
+	if redirect != nil {
+		if err := redirect.Shutdown(ctx); err != nil {
+			logger.Error("http_redirect_shutdown_failed", zap.Error(err))
+		}
+	}
+	if services.Answers != nil {
+		if err := services.Answers.Stop(ctx); err != nil {
+			logger.Error("answer_worker_shutdown_failed", zap.Error(err))
+		}
+	}
+	pool.Close()
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("tracing_shutdown_failed", zap.Error(err))
+	}
+	logger.Info("shutdown complete")
+	logger.Sync()
+}
+
+func warmSimilarityIndex(ctx context.Context, detector *similarity.Detector, studentHandler *handlers.StudentHandler) {
+	questions, err := studentHandler.FetchAnsweredQuestions(ctx)
+	if err != nil {
+		config.GetLogger().Warn("similarity_warmup_failed", zap.Error(err))
+		return
+	}
+	for _, q := range questions {
+		if err := detector.Remember(ctx, q.ScsID, q.MessageID, q.ChatID, q.Question, q.Answer); err != nil {
+			config.GetLogger().Warn("similarity_warmup_failed", zap.Error(err))
+			return
+		}
+	}
+	config.GetLogger().Info("similarity index warmed", zap.Int("questions", len(questions)))
+}
+
+func migrateOnStartup(pool *pgxpool.Pool) {
+	migrator, err := migrations.NewMigrator(pool)
+	if err != nil {
+		config.GetLogger().Fatal("migrations_load_failed", zap.Error(err))
+	}
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		config.GetLogger().Fatal("migrations_failed", zap.Error(err))
+	}
+	config.GetLogger().Info("migrations applied", zap.Int("count", len(applied)), zap.Int64("version", migrator.Latest()))
+}
+
+// newCacheStore layers the in-process LRU over memcache, leaving out whichever is disabled.
+func newCacheStore(mc *memcache.Client) *cache.Store {
+	env := config.GetEnv()
+	var local, remote cache.Cache = cache.Noop{}, cache.Noop{}
+	if env.CacheLocalSize > 0 {
+		local = cache.NewLRU(env.CacheLocalSize)
+	}
+	if mc != nil {
+		remote = cache.NewMemcache(mc, "query")
+	}
+	store := cache.New(cache.NewTiered(local, remote, env.CacheLocalTTL))
+	store.LoadTimeout = env.RequestTimeout
+	return store
+}
+
+func healthChecks(pool *pgxpool.Pool, mc *memcache.Client, services routes.Services) []health.Check {
+	checks := []health.Check{
+		{Name: "database", Run: pool.Ping},
+	}
+
+	if migrator, err := migrations.NewMigrator(pool); err == nil {
+		checks = append(checks, health.Check{Name: "migrations", Run: func(ctx context.Context) error {
+			current, err := migrator.Current(ctx)
+			if err != nil {
+				return err
+			}
+			// a newer schema is fine during rolling deploys, an older one is not
+			if current < migrator.Latest() {
+				return fmt.Errorf("schema is at version %d, binary expects %d", current, migrator.Latest())
+			}
+			return nil
+		}})
+	}
+
+	if mc != nil {
+		checks = append(checks, health.Check{Name: "memcache", Run: func(ctx context.Context) error {
+			return mc.Ping()
+		}})
+	}
+
+	if services.Answers != nil {
+		checks = append(checks, health.Check{Name: "answer_worker", Run: func(ctx context.Context) error {
+			if !services.Answers.Running() {
+				return errors.New("answer worker is not running")
+			}
+			return nil
+		}})
+	}
+	return checks
+}
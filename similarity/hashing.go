@@ -0,0 +1,47 @@
+package similarity
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+// HashingEmbedder is a local bag-of-words embedder using the hashing trick.
+// It needs no model or network access; swap it for a real embedding service
+// by implementing Embedder.
+type HashingEmbedder struct {
+	Dimensions int
+}
+
+func NewHashingEmbedder(dimensions int) *HashingEmbedder {
+	if dimensions <= 0 {
+		dimensions = 256
+	}
+	return &HashingEmbedder{Dimensions: dimensions}
+}
+
+func (h *HashingEmbedder) Embed(_ context.Context, text string) ([]float32, error) {
+	vector := make([]float32, h.Dimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for i, word := range words {
+		h.add(vector, word, 1)
+		if i > 0 {
+			// bigrams keep some word order information
+			h.add(vector, words[i-1]+" "+word, 0.5)
+		}
+	}
+	return vector, nil
+}
+
+func (h *HashingEmbedder) add(vector []float32, token string, weight float32) {
+	hasher := fnv.New32a()
+	hasher.Write([]byte(token))
+	sum := hasher.Sum32()
+	if sum&1 == 1 {
+		weight = -weight
+	}
+	vector[(sum>>1)%uint32(len(vector))] += weight
+}
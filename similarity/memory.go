@@ -0,0 +1,83 @@
+package similarity
+
+import (
+	"backend/models"
+	"context"
+	"math"
+	"sort"
+	"sync"
+)
+
+// MemoryIndex is a brute-force, in-process embedding index. It is exact and
+// dependency free, which makes it suitable for tests and small deployments.
+type MemoryIndex struct {
+	mu      sync.RWMutex
+	entries map[string]map[string]Entry // scs_id -> message_id -> entry
+}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{entries: map[string]map[string]Entry{}}
+}
+
+func (m *MemoryIndex) Upsert(_ context.Context, entry Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	scs, ok := m.entries[entry.ScsID]
+	if !ok {
+		scs = map[string]Entry{}
+		m.entries[entry.ScsID] = scs
+	}
+	scs[entry.MessageID] = entry
+	return nil
+}
+
+func (m *MemoryIndex) Search(_ context.Context, scsID string, vector []float32, limit int) ([]models.SimilarQuestion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := make([]models.SimilarQuestion, 0, len(m.entries[scsID]))
+	for _, entry := range m.entries[scsID] {
+		results = append(results, models.SimilarQuestion{
+			MessageID: entry.MessageID,
+			ChatID:    entry.ChatID,
+			Question:  entry.Question,
+			Answer:    entry.Answer,
+			Score:     cosine(vector, entry.Vector),
+			Source:    "embedding",
+		})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// Len reports the number of indexed questions.
+func (m *MemoryIndex) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	n := 0
+	for _, scs := range m.entries {
+		n += len(scs)
+	}
+	return n
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
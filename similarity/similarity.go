@@ -0,0 +1,123 @@
+package similarity
+
+import (
+	"backend/models"
+	"context"
+	"sort"
+)
+
+// Embedder turns a question into a vector that can be compared with cosine similarity.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// Entry is an answered question stored in an embedding index.
+type Entry struct {
+	MessageID string
+	ChatID    string
+	ScsID     string
+	Question  string
+	Answer    string
+	Vector    []float32
+}
+
+// Index stores question embeddings partitioned by SCS.
+type Index interface {
+	Upsert(ctx context.Context, entry Entry) error
+	Search(ctx context.Context, scsID string, vector []float32, limit int) ([]models.SimilarQuestion, error)
+}
+
+// LexicalSearcher finds answered questions with a similar spelling, e.g. using pg_trgm.
+type LexicalSearcher interface {
+	FetchSimilarQuestions(ctx context.Context, scsID string, question string, minScore float64, limit int) ([]models.SimilarQuestion, error)
+}
+
+type Detector struct {
+	Lexical  LexicalSearcher
+	Embedder Embedder
+	Index    Index
+	// MinScore is the lowest similarity (0..1) reported as a duplicate.
+	MinScore float64
+	Limit    int
+}
+
+func NewDetector(lexical LexicalSearcher, embedder Embedder, index Index, minScore float64, limit int) *Detector {
+	return &Detector{
+		Lexical:  lexical,
+		Embedder: embedder,
+		Index:    index,
+		MinScore: minScore,
+		Limit:    limit,
+	}
+}
+
+// Find returns answered questions of the same SCS similar to question, best match first.
+// Lexical and embedding matches are merged by message, keeping the higher score.
+func (d *Detector) Find(ctx context.Context, scsID string, question string) ([]models.SimilarQuestion, error) {
+	byMessage := map[string]models.SimilarQuestion{}
+	add := func(matches []models.SimilarQuestion) {
+		for _, m := range matches {
+			if m.Score < d.MinScore {
+				continue
+			}
+			if prev, ok := byMessage[m.MessageID]; ok && prev.Score >= m.Score {
+				continue
+			}
+			byMessage[m.MessageID] = m
+		}
+	}
+
+	if d.Lexical != nil {
+		matches, err := d.Lexical.FetchSimilarQuestions(ctx, scsID, question, d.MinScore, d.Limit)
+		if err != nil {
+			return nil, err
+		}
+		add(matches)
+	}
+
+	if d.Embedder != nil && d.Index != nil {
+		vector, err := d.Embedder.Embed(ctx, question)
+		if err != nil {
+			return nil, err
+		}
+		matches, err := d.Index.Search(ctx, scsID, vector, d.Limit)
+		if err != nil {
+			return nil, err
+		}
+		add(matches)
+	}
+
+	results := make([]models.SimilarQuestion, 0, len(byMessage))
+	for _, m := range byMessage {
+		results = append(results, m)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].MessageID < results[j].MessageID
+		}
+		return results[i].Score > results[j].Score
+	})
+	if d.Limit > 0 && len(results) > d.Limit {
+		results = results[:d.Limit]
+	}
+	return results, nil
+}
+
+// Remember adds an answered question to the embedding index so later askers can reuse it.
+func (d *Detector) Remember(ctx context.Context, scsID string, messageID string, chatID string, question string, answer string) error {
+	if d.Embedder == nil || d.Index == nil {
+		return nil
+	}
+	vector, err := d.Embedder.Embed(ctx, question)
+	if err != nil {
+		return err
+	}
+	return d.Index.Upsert(ctx, Entry{
+		MessageID: messageID,
+		ChatID:    chatID,
+		ScsID:     scsID,
+		Question:  question,
+		Answer:    answer,
+		Vector:    vector,
+	})
+}
@@ -0,0 +1,172 @@
+package similarity_test
+
+import (
+	"backend/models"
+	"backend/similarity"
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestHashingEmbedder(t *testing.T) {
+	ctx := context.Background()
+	embedder := similarity.NewHashingEmbedder(0)
+	a, _ := embedder.Embed(ctx, "What is photosynthesis?")
+	if len(a) != 256 {
+		t.Fatalf("got %d dimensions, want the default 256", len(a))
+	}
+	// case and punctuation do not matter
+	b, _ := embedder.Embed(ctx, "what IS photosynthesis")
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("dimension %d: got %v and %v for the same words", i, a[i], b[i])
+		}
+	}
+}
+
+// index holds three questions of scs-1 and one of scs-2.
+func index(t *testing.T) (*similarity.MemoryIndex, similarity.Embedder) {
+	t.Helper()
+	ctx := context.Background()
+	embedder := similarity.NewHashingEmbedder(512)
+	idx := similarity.NewMemoryIndex()
+	for _, q := range []struct{ id, scs, text string }{
+		{"m1", "scs-1", "what is photosynthesis"},
+		{"m2", "scs-1", "how do plants make food from sunlight"},
+		{"m3", "scs-1", "who won the world cup"},
+		{"m4", "scs-2", "what is photosynthesis"},
+	} {
+		vector, err := embedder.Embed(ctx, q.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := idx.Upsert(ctx, similarity.Entry{MessageID: q.id, ChatID: "chat-" + q.id, ScsID: q.scs, Question: q.text, Vector: vector}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return idx, embedder
+}
+
+func TestMemoryIndexSearch(t *testing.T) {
+	ctx := context.Background()
+	idx, embedder := index(t)
+	if idx.Len() != 4 {
+		t.Fatalf("got %d entries, want 4", idx.Len())
+	}
+
+	vector, _ := embedder.Embed(ctx, "What is photosynthesis?")
+	results, err := idx.Search(ctx, "scs-1", vector, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].MessageID != "m1" || math.Abs(results[0].Score-1) > 1e-6 || results[0].Source != "embedding" {
+		t.Fatalf("got %+v, want m1 first with score 1", results)
+	}
+	if results[1].Score > results[0].Score {
+		t.Fatalf("results not sorted by score: %+v", results)
+	}
+	for _, r := range results {
+		if r.MessageID == "m4" {
+			t.Fatal("search returned a question of another SCS")
+		}
+	}
+
+	// upserting the same message replaces it
+	other, _ := embedder.Embed(ctx, "who won the world cup")
+	idx.Upsert(ctx, similarity.Entry{MessageID: "m1", ScsID: "scs-1", Question: "who won the world cup", Vector: other})
+	if idx.Len() != 4 {
+		t.Fatalf("got %d entries after replacing one, want 4", idx.Len())
+	}
+	results, _ = idx.Search(ctx, "scs-1", vector, 0)
+	for _, r := range results {
+		if r.MessageID == "m1" && (r.Question != "who won the world cup" || r.Score > 0.5) {
+			t.Fatalf("replaced entry still matches its old question: %+v", r)
+		}
+	}
+	if results, _ := idx.Search(ctx, "scs-3", vector, 1); len(results) != 0 {
+		t.Fatalf("got %+v for an empty SCS", results)
+	}
+}
+
+type lexical struct {
+	matches []models.SimilarQuestion
+	err     error
+}
+
+func (l lexical) FetchSimilarQuestions(ctx context.Context, scsID string, question string, minScore float64, limit int) ([]models.SimilarQuestion, error) {
+	return l.matches, l.err
+}
+
+type fixedIndex []models.SimilarQuestion
+
+func (i fixedIndex) Upsert(context.Context, similarity.Entry) error { return nil }
+func (i fixedIndex) Search(context.Context, string, []float32, int) ([]models.SimilarQuestion, error) {
+	return i, nil
+}
+
+func TestDetectorFindMergesByMessage(t *testing.T) {
+	detector := similarity.NewDetector(
+		lexical{matches: []models.SimilarQuestion{
+			{MessageID: "a", Score: 0.9, Source: "trigram"},
+			{MessageID: "b", Score: 0.65, Source: "trigram"},
+			{MessageID: "low", Score: 0.3, Source: "trigram"},
+		}},
+		similarity.NewHashingEmbedder(16),
+		fixedIndex{
+			{MessageID: "a", Score: 0.7, Source: "embedding"},
+			{MessageID: "b", Score: 0.8, Source: "embedding"},
+			{MessageID: "c", Score: 0.8, Source: "embedding"},
+			{MessageID: "d", Score: 0.61, Source: "embedding"},
+		},
+		0.6, 3,
+	)
+	results, err := detector.Find(context.Background(), "scs-1", "question")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		id     string
+		score  float64
+		source string
+	}{{"a", 0.9, "trigram"}, {"b", 0.8, "embedding"}, {"c", 0.8, "embedding"}}
+	if len(results) != len(want) {
+		t.Fatalf("got %+v, want %d results", results, len(want))
+	}
+	for i, w := range want {
+		if r := results[i]; r.MessageID != w.id || r.Score != w.score || r.Source != w.source {
+			t.Errorf("result %d: got %+v, want %s with %v from %s", i, r, w.id, w.score, w.source)
+		}
+	}
+}
+
+func TestDetectorFindWithoutEmbeddings(t *testing.T) {
+	failing := similarity.NewDetector(lexical{err: errors.New("db down")}, nil, nil, 0.5, 3)
+	if _, err := failing.Find(context.Background(), "scs-1", "q"); err == nil {
+		t.Fatal("lexical search error was swallowed")
+	}
+	if err := failing.Remember(context.Background(), "scs-1", "m1", "c1", "q", "a"); err != nil {
+		t.Fatalf("Remember without an index: %v", err)
+	}
+
+	none := similarity.NewDetector(nil, nil, nil, 0.5, 3)
+	if results, err := none.Find(context.Background(), "scs-1", "q"); err != nil || len(results) != 0 {
+		t.Fatalf("got %+v, %v without any searcher", results, err)
+	}
+}
+
+func TestDetectorRemembersAnsweredQuestions(t *testing.T) {
+	ctx := context.Background()
+	detector := similarity.NewDetector(nil, similarity.NewHashingEmbedder(128), similarity.NewMemoryIndex(), 0.8, 3)
+	if err := detector.Remember(ctx, "scs-1", "m1", "c1", "What is photosynthesis?", "Plants turn light into energy."); err != nil {
+		t.Fatal(err)
+	}
+	results, err := detector.Find(ctx, "scs-1", "what is photosynthesis")
+	if err != nil || len(results) != 1 || results[0].Answer != "Plants turn light into energy." || results[0].ChatID != "c1" {
+		t.Fatalf("got %+v, %v", results, err)
+	}
+	if results, _ := detector.Find(ctx, "scs-1", "who won the world cup"); len(results) != 0 {
+		t.Fatalf("unrelated question matched: %+v", results)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"backend/config"
+	"backend/handlers"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type tokenResult struct {
+	Token     string    `json:"token"`
+	UserID    string    `json:"user_id"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// token issues a JWT for an existing, enabled user so support can reproduce what they see.
+func token(args []string) int {
+	if len(args) == 0 || args[0] != "issue" {
+		return usageError("token needs issue")
+	}
+
+	flags := flag.NewFlagSet("token issue", flag.ContinueOnError)
+	role := flags.String("role", config.RoleStudent, "student, teacher or admin")
+	email := flags.String("email", "", "email of the user to impersonate")
+	ttl := flags.Duration("ttl", time.Hour, "token lifetime")
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	if *email == "" {
+		return usageError("--email is required")
+	}
+
+	pool, err := config.Connect(context.Background())
+	if err != nil {
+		return printError(err)
+	}
+	defer pool.Close()
+
+	userHandler := handlers.UserHandler{DB: pool}
+	account, err := userHandler.FetchUserByEmail(context.Background(), *role, *email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return printError(fmt.Errorf("%s %s not found", *role, *email))
+	}
+	if err != nil {
+		return printError(err)
+	}
+	if account.DisabledAt != nil {
+		return printError(fmt.Errorf("%s %s is disabled", *role, *email))
+	}
+
+	expiresAt := time.Now().Add(*ttl)
+	signed, err := config.GenerateJWTWithTTL(account.ID, account.Email, *role, *ttl)
+	if err != nil {
+		return printError(err)
+	}
+	printJSON(tokenResult{Token: signed, UserID: account.ID, Role: *role, ExpiresAt: expiresAt})
+	return exitOK
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"backend/config"
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Tracer is used for spans this service creates by hand (memcache, answer worker).
+var Tracer trace.Tracer = otel.Tracer("backend")
+
+// Init installs the global tracer provider and W3C trace-context propagator.
+// The OTLP exporter is configured by the standard OTEL_EXPORTER_OTLP_* variables.
+// The returned function flushes pending spans and must be called on shutdown.
+func Init(ctx context.Context) (func(context.Context) error, error) {
+	env := config.GetEnv()
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch env.TracesExporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr), stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown traces exporter %q, expected none, otlp or stdout", env.TracesExporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// semconv must match the schema of the SDK's resource.Default or Merge fails
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(env.ServiceName),
+		semconv.ServiceVersion(config.Version),
+		attribute.String("vcs.commit", config.Commit),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(env.TracesSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// StartMemcacheSpan starts a client span for a memcache operation such as "get" or "set".
+func StartMemcacheSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return Tracer.Start(ctx, "memcache."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameMemcached,
+			semconv.DBOperationName(operation),
+		),
+	)
+}
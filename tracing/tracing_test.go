@@ -0,0 +1,53 @@
+package tracing_test
+
+import (
+	"backend/config"
+	"backend/tracing"
+	"context"
+	"os"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestMain(m *testing.M) {
+	config.InitLogger()
+	if err := config.ConfigureLogger("fatal", "json"); err != nil {
+		panic(err)
+	}
+	config.LoadEnv()
+	os.Exit(m.Run())
+}
+
+func TestInitStdout(t *testing.T) {
+	env := config.GetEnv()
+	saved := *env
+	t.Cleanup(func() { *env = saved })
+	provider := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(provider) })
+
+	env.TracesExporter = tracing.ExporterStdout
+	env.TracesSampleRatio = 1
+	env.ServiceName = "backend-test"
+	shutdown, err := tracing.Init(context.Background())
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	_, span := tracing.StartMemcacheSpan(context.Background(), "get")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+}
+
+func TestInitUnknownExporter(t *testing.T) {
+	env := config.GetEnv()
+	saved := *env
+	t.Cleanup(func() { *env = saved })
+
+	env.TracesExporter = "jaeger"
+	if _, err := tracing.Init(context.Background()); err == nil {
+		t.Fatal("unknown exporter: got nil error")
+	}
+}
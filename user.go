@@ -0,0 +1,159 @@
+package main
+
+import (
+	"backend/config"
+	"backend/handlers"
+	"backend/models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type userResult struct {
+	User models.UserAccount `json:"user"`
+	// Password is only printed when the command generated it.
+	Password string `json:"password,omitempty"`
+}
+
+func user(args []string) int {
+	if len(args) == 0 {
+		return usageError("user needs create, disable or reset-password")
+	}
+
+	switch args[0] {
+	case "create":
+		return userCreate(args[1:])
+	case "disable":
+		return userDisable(args[1:])
+	case "reset-password":
+		return userResetPassword(args[1:])
+	}
+	return usageError("unknown user command %q", args[0])
+}
+
+func userCreate(args []string) int {
+	flags := flag.NewFlagSet("user create", flag.ContinueOnError)
+	role := flags.String("role", config.RoleStudent, "student, teacher or admin")
+	id := flags.String("id", "", "student_id or teacher_id")
+	name := flags.String("name", "", "full name")
+	email := flags.String("email", "", "login email")
+	phone := flags.String("phone", "", "phone number")
+	school := flags.String("school", "", "school id (teachers only)")
+	password := flags.String("password", "", "password, generated when empty")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *name == "" || *email == "" {
+		return usageError("--name and --email are required")
+	}
+	if *role != config.RoleAdmin && *id == "" {
+		return usageError("--id is required for %s accounts", *role)
+	}
+
+	generated := ""
+	if *password == "" {
+		generated = generatePassword()
+		*password = generated
+	}
+
+	pool, err := config.Connect(context.Background())
+	if err != nil {
+		return printError(err)
+	}
+	defer pool.Close()
+
+	userHandler := handlers.UserHandler{DB: pool}
+	account, err := userHandler.CreateUser(context.Background(), models.CreateUserRequest{
+		Role:       *role,
+		ExternalID: *id,
+		FullName:   *name,
+		Email:      *email,
+		Phone:      *phone,
+		School:     *school,
+		Password:   *password,
+	})
+	if handlers.IsUniqueViolation(err) {
+		return printError(fmt.Errorf("a %s with this id or email already exists", *role))
+	}
+	if err != nil {
+		return printError(err)
+	}
+	printJSON(userResult{User: account, Password: generated})
+	return exitOK
+}
+
+func userDisable(args []string) int {
+	flags := flag.NewFlagSet("user disable", flag.ContinueOnError)
+	role := flags.String("role", config.RoleStudent, "student, teacher or admin")
+	email := flags.String("email", "", "login email")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *email == "" {
+		return usageError("--email is required")
+	}
+
+	pool, err := config.Connect(context.Background())
+	if err != nil {
+		return printError(err)
+	}
+	defer pool.Close()
+
+	userHandler := handlers.UserHandler{DB: pool}
+	account, err := userHandler.DisableUser(context.Background(), *role, *email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return printError(fmt.Errorf("%s %s not found", *role, *email))
+	}
+	if err != nil {
+		return printError(err)
+	}
+	printJSON(userResult{User: account})
+	return exitOK
+}
+
+func userResetPassword(args []string) int {
+	flags := flag.NewFlagSet("user reset-password", flag.ContinueOnError)
+	role := flags.String("role", config.RoleStudent, "student, teacher or admin")
+	email := flags.String("email", "", "login email")
+	password := flags.String("password", "", "new password, generated when empty")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *email == "" {
+		return usageError("--email is required")
+	}
+
+	generated := ""
+	if *password == "" {
+		generated = generatePassword()
+		*password = generated
+	}
+
+	pool, err := config.Connect(context.Background())
+	if err != nil {
+		return printError(err)
+	}
+	defer pool.Close()
+
+	userHandler := handlers.UserHandler{DB: pool}
+	account, err := userHandler.ResetPassword(context.Background(), *role, *email, *password)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return printError(fmt.Errorf("%s %s not found", *role, *email))
+	}
+	if err != nil {
+		return printError(err)
+	}
+	printJSON(userResult{User: account, Password: generated})
+	return exitOK
+}
+
+func generatePassword() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,144 @@
+// Package validation configures the validator behind gin's `binding` struct tags and
+// turns its errors into per-field reasons for API clients.
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// PasswordMinLength is the shortest password the "password" tag accepts.
+const PasswordMinLength = 8
+
+// FieldError says why one request field was rejected. Field is the JSON name, or the
+// path parameter name for URL params.
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+var registerOnce sync.Once
+
+// Register adds the custom tags to gin's validator and makes field errors report JSON
+// names. It is safe to call more than once.
+func Register() {
+	registerOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			panic("validation: gin is not using go-playground/validator")
+		}
+		v.RegisterTagNameFunc(jsonName)
+		v.RegisterValidation("notblank", notBlank)
+		v.RegisterValidation("password", strongPassword)
+	})
+}
+
+func jsonName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "uri", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// notBlank rejects strings that are empty once surrounding whitespace is removed.
+func notBlank(fl validator.FieldLevel) bool {
+	return strings.TrimSpace(fl.Field().String()) != ""
+}
+
+// strongPassword requires PasswordMinLength characters including a letter and a digit.
+func strongPassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+	if len([]rune(password)) < PasswordMinLength {
+		return false
+	}
+	var letter, digit bool
+	for _, r := range password {
+		letter = letter || unicode.IsLetter(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+	return letter && digit
+}
+
+// UUID checks a single value, e.g. a path parameter, against the "uuid" tag.
+func UUID(name string, value string) []FieldError {
+	Register()
+	err := binding.Validator.Engine().(*validator.Validate).Var(value, "required,uuid")
+	if err == nil {
+		return nil
+	}
+	fields := Fields(err)
+	for i := range fields {
+		fields[i].Field = name
+	}
+	return fields
+}
+
+// Fields explains a binding error field by field. Errors that are not about a single
+// field, such as malformed JSON, are reported against "body".
+func Fields(err error) []FieldError {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		fields := make([]FieldError, 0, len(validationErrors))
+		for _, fe := range validationErrors {
+			fields = append(fields, FieldError{Field: fieldPath(fe), Reason: reason(fe)})
+		}
+		return fields
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) && typeError.Field != "" {
+		return []FieldError{{Field: typeError.Field, Reason: "must be a " + typeError.Type.Kind().String()}}
+	}
+	return []FieldError{{Field: "body", Reason: "must be a valid JSON object"}}
+}
+
+// fieldPath drops the struct name validator puts in front, so nested fields read
+// like "ladder[0]".
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+func reason(fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+	switch fe.Tag() {
+	case "required", "notblank":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "uuid", "uuid4":
+		return "must be a UUID"
+	case "password":
+		return fmt.Sprintf("must be at least %d characters and contain a letter and a digit", PasswordMinLength)
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min", "gte":
+		if isString {
+			return "must be at least " + fe.Param() + " characters"
+		}
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		if isString {
+			return "must be at most " + fe.Param() + " characters"
+		}
+		return "must be at most " + fe.Param()
+	}
+	return "failed the " + fe.Tag() + " check"
+}
@@ -0,0 +1,57 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+type signup struct {
+	Email    string   `json:"email" binding:"required,email"`
+	Password string   `json:"password" binding:"required,password"`
+	Name     string   `json:"name" binding:"notblank,max=5"`
+	Year     int      `json:"year" binding:"min=2000"`
+	Ladder   []string `json:"ladder" binding:"dive,notblank"`
+}
+
+func TestFields(t *testing.T) {
+	Register()
+	err := binding.Validator.ValidateStruct(signup{Email: "not-an-email", Password: "letters-only", Name: "  ", Year: 1999, Ladder: []string{"Class 8", " "}})
+	want := []FieldError{
+		{"email", "must be a valid email address"},
+		{"password", "must be at least 8 characters and contain a letter and a digit"},
+		{"name", "is required"},
+		{"year", "must be at least 2000"},
+		{"ladder[1]", "is required"},
+	}
+	if got := Fields(err); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	Register()
+	for password, ok := range map[string]bool{
+		"secret-123": true,
+		"abcdefg1":   true,
+		"abc1":       false,
+		"12345678":   false,
+		"abcdefgh":   false,
+	} {
+		err := binding.Validator.ValidateStruct(signup{Email: "a@b.co", Password: password, Name: "x", Year: 2000})
+		if (err == nil) != ok {
+			t.Errorf("%q: got %v, want ok=%v", password, err, ok)
+		}
+	}
+}
+
+func TestUUID(t *testing.T) {
+	if got := UUID("id", "00000000-0000-4000-8000-000000000000"); got != nil {
+		t.Fatalf("valid UUID: got %+v", got)
+	}
+	want := []FieldError{{"student_id", "must be a UUID"}}
+	if got := UUID("student_id", "42"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}